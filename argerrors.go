@@ -0,0 +1,137 @@
+package sugar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// An ArgErrorCode is a machine-readable description of what was wrong with an argument.
+type ArgErrorCode string
+
+// The codes used by LoadArgs.
+const (
+	// ErrCodeRequired means a required argument was not provided.
+	ErrCodeRequired ArgErrorCode = "REQUIRED"
+	// ErrCodeInvalidType means the provided value could not be converted to the Go type.
+	ErrCodeInvalidType ArgErrorCode = "INVALID_TYPE"
+	// ErrCodeValidation means the provided value was converted, but failed validation.
+	ErrCodeValidation ArgErrorCode = "VALIDATION"
+)
+
+// An ArgError describes a problem with a single argument, or with a value nested inside one.
+type ArgError struct {
+	// Arg is the name of the top level argument.
+	Arg string
+	// Path locates the bad value within the argument, like "address.zip" or "favoriteMovies[3]".
+	Path string
+	// GoType is the type the value was being loaded into.
+	GoType reflect.Type
+	// ExpectedType is the name of the GraphQL type for the value, if known.
+	ExpectedType string
+	// Code says what kind of problem this is.
+	Code ArgErrorCode
+	// Err is the underlying error from the loader or validator, if any.
+	Err error
+}
+
+func newArgError(code ArgErrorCode, path string, t reflect.Type, expected string, cause error) *ArgError {
+	arg := path
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		arg = path[:i]
+	}
+	return &ArgError{
+		Arg:          arg,
+		Path:         path,
+		GoType:       t,
+		ExpectedType: expected,
+		Code:         code,
+		Err:          cause,
+	}
+}
+
+func (e *ArgError) Error() string {
+	switch {
+	case e.Code == ErrCodeRequired:
+		return fmt.Sprintf("%s is required", e.Path)
+	case e.Code == ErrCodeValidation && e.Err != nil:
+		return fmt.Sprintf("%s %v", e.Path, e.Err)
+	case e.Err != nil:
+		return fmt.Sprintf("%s is not valid: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s is not valid", e.Path)
+}
+
+// Unwrap returns the underlying loader or validator error.
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+// Extensions describes the error for the "extensions" field of a GraphQL error response.
+func (e *ArgError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{
+		"code":     string(e.Code),
+		"argument": e.Arg,
+		"path":     e.Path,
+		"message":  e.Error(),
+	}
+	if e.GoType != nil {
+		ext["goType"] = e.GoType.String()
+	}
+	if e.ExpectedType != "" {
+		ext["expectedType"] = e.ExpectedType
+	}
+	return ext
+}
+
+// ArgErrors collects all the problems found by LoadArgs.  It implements graphql-go's
+// gqlerrors.ExtendedError, so a resolver that returns it gives clients one structured entry per bad
+// argument in the error's extensions.
+type ArgErrors []*ArgError
+
+func (errs ArgErrors) Error() string {
+	if len(errs) == 1 {
+		return fmt.Sprintf("1 error occurred:\n\t* %s\n\n", errs[0])
+	}
+	points := make([]string, len(errs))
+	for i, err := range errs {
+		points[i] = fmt.Sprintf("* %s", err)
+	}
+	return fmt.Sprintf("%d errors occurred:\n\t%s\n\n", len(errs), strings.Join(points, "\n\t"))
+}
+
+// Extensions describes every argument error for the "extensions" field of a GraphQL error response.
+func (errs ArgErrors) Extensions() map[string]interface{} {
+	args := make([]map[string]interface{}, len(errs))
+	for i, err := range errs {
+		args[i] = err.Extensions()
+	}
+	return map[string]interface{}{
+		"code":      "BAD_ARGUMENTS",
+		"arguments": args,
+	}
+}
+
+// add appends an error to the collection.
+func (errs *ArgErrors) add(err *ArgError) {
+	*errs = append(*errs, err)
+}
+
+// addNested appends errors found inside the value at path, with their paths prefixed by it.
+func (errs *ArgErrors) addNested(path string, nested ArgErrors) {
+	for _, err := range nested {
+		sep := "."
+		if err.Path == "" || strings.HasPrefix(err.Path, "[") {
+			sep = ""
+		}
+		errs.add(newArgError(err.Code, path+sep+err.Path, err.GoType, err.ExpectedType, err.Err))
+	}
+}
+
+// errorOrNil returns the collection as an error, or nil if it's empty.
+func (errs ArgErrors) errorOrNil() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
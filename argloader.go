@@ -1,53 +1,79 @@
 package sugar
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/graphql-go/graphql"
-	multierror "github.com/hashicorp/go-multierror"
+	"github.com/lib/pq"
 )
 
-type tagKey string
-
 const (
-	defaultTag                = "arg"
-	defaultSeparator          = ","
-	defaultHidden             = "-"
-	defaultAssignor           = ":"
-	descTag                   = "desc"
-	tagKeyRequired     tagKey = "required"
-	tagKeyCoalesceZero tagKey = "coalesceZero"
+	defaultTag         = "arg"
+	defaultSeparator   = ","
+	defaultHidden      = "-"
+	defaultAssignor    = ":"
+	defaultDescTag     = "desc"
+	tagKeyRequired     = "required"
+	tagKeyCoalesceZero = "coalesceZero"
+	tagKeyDefault      = "default"
 )
 
 // DefaultLoaders are for extra types beyond the 4 scalar types built into GraphQL.
 var DefaultLoaders = []struct {
 	LoaderFunc interface{}
-	GqlType    graphql.Output
+	GqlType    graphql.Input
 }{
 	// Go std library types.  Keep these.
 	{LoaderFunc: LoadRawJSON, GqlType: JSON},
-	{LoaderFunc: LoadUInt, GqlType: graphql.Int},
+	{LoaderFunc: LoadUInt, GqlType: Long},
+	{LoaderFunc: LoadInt64, GqlType: Long},
+	{LoaderFunc: LoadUInt32, GqlType: Long},
+	{LoaderFunc: LoadUInt64, GqlType: Long},
+
+	// nullable wrapper types.  An explicit null loads as an invalid wrapper.
+	{LoaderFunc: LoadNullInt, GqlType: Long},
+	{LoaderFunc: LoadNullString, GqlType: graphql.String},
+	{LoaderFunc: LoadNullBool, GqlType: graphql.Boolean},
+	{LoaderFunc: LoadNullFloat, GqlType: graphql.Float},
+	{LoaderFunc: LoadNullTime, GqlType: Timestamp},
+	{LoaderFunc: LoadSQLNullString, GqlType: graphql.String},
+	{LoaderFunc: LoadSQLNullInt64, GqlType: Long},
+	{LoaderFunc: LoadSQLNullInt32, GqlType: graphql.Int},
+	{LoaderFunc: LoadSQLNullInt16, GqlType: graphql.Int},
+	{LoaderFunc: LoadSQLNullByte, GqlType: graphql.Int},
+	{LoaderFunc: LoadSQLNullFloat64, GqlType: graphql.Float},
+	{LoaderFunc: LoadSQLNullBool, GqlType: graphql.Boolean},
+	{LoaderFunc: LoadSQLNullTime, GqlType: Timestamp},
 }
 
 // BaseLoaders are for the 4 scalar types built into GraphQL.
 var BaseLoaders = []struct {
 	LoaderFunc interface{}
-	GqlType    graphql.Output
+	GqlType    graphql.Input
 }{
 	{LoaderFunc: LoadBool, GqlType: graphql.Boolean},
 	{LoaderFunc: LoadBoolPointer, GqlType: graphql.Boolean},
-	{LoaderFunc: LoadString, GqlType: graphql.String},
+	{LoaderFunc: LoadStringWithOptions, GqlType: graphql.String},
 	{LoaderFunc: LoadInt, GqlType: graphql.Int},
+	{LoaderFunc: LoadInt8, GqlType: graphql.Int},
+	{LoaderFunc: LoadInt16, GqlType: graphql.Int},
+	{LoaderFunc: LoadInt32, GqlType: graphql.Int},
+	{LoaderFunc: LoadUInt8, GqlType: graphql.Int},
+	{LoaderFunc: LoadUInt16, GqlType: graphql.Int},
 	{LoaderFunc: LoadFloat, GqlType: graphql.Float},
-	{LoaderFunc: LoadTime, GqlType: Timestamp},
+	{LoaderFunc: LoadFloat32, GqlType: graphql.Float},
+	{LoaderFunc: LoadTimeWithOptions, GqlType: Timestamp},
 }
 
 // New returns a ArgLoader with all default loader funcs enabled.
-func New() (*ArgLoader, error) {
-	ec, err := Base()
+func New(opts ...Option) (*ArgLoader, error) {
+	ec, err := Base(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not load default arg funcs: %v", err)
 	}
@@ -57,12 +83,18 @@ func New() (*ArgLoader, error) {
 			return nil, err
 		}
 	}
+	// newer versions of lib/pq make pq.NullTime an alias of sql.NullTime, which is loaded above.
+	if reflect.TypeOf(pq.NullTime{}) != reflect.TypeOf(sql.NullTime{}) {
+		if err := ec.RegisterArgParser(LoadPQNullTime, Timestamp); err != nil {
+			return nil, err
+		}
+	}
 	return ec, nil
 }
 
 // Base returns a ArgLoader with the 4 base loader funcs enabled.
-func Base() (*ArgLoader, error) {
-	ec := Empty()
+func Base(opts ...Option) (*ArgLoader, error) {
+	ec := Empty(opts...)
 	for _, l := range BaseLoaders {
 		err := ec.RegisterArgParser(l.LoaderFunc, l.GqlType)
 		if err != nil {
@@ -73,22 +105,79 @@ func Base() (*ArgLoader, error) {
 }
 
 // Empty returns a ArgLoader without any loader funcs enabled.
-func Empty() *ArgLoader {
-	ec := &ArgLoader{}
-	ec.loaderFuncs = map[reflect.Type]func(interface{}, map[tagKey]string) (reflect.Value, error){}
-	ec.gqlTypes = map[reflect.Type]graphql.Output{}
+func Empty(opts ...Option) *ArgLoader {
+	ec := &ArgLoader{
+		tag:        defaultTag,
+		descTag:    defaultDescTag,
+		separator:  defaultSeparator,
+		assignor:   defaultAssignor,
+		nameMapper: func(fieldName string) string { return fieldName },
+	}
+	reg := &argRegistry{
+		loaderFuncs:   map[reflect.Type]loaderFunc{},
+		gqlTypes:      map[reflect.Type]graphql.Input{},
+		validators:    builtinValidators(),
+		plans:         &sync.Map{},
+		inputObjects:  &sync.Map{},
+		filterLoaders: &sync.Map{},
+	}
+	ec.reg.Store(reg)
+	for _, opt := range opts {
+		opt(ec)
+	}
 	return ec
 }
 
 // ArgLoader is a helper for reading arguments from a graphql.ResolveParams, converting them to Go
 // types, and setting their values to fields on a user-provided struct.
 type ArgLoader struct {
-	// a map from reflect types to functions that can take an interface and return a
-	// reflect value of that type.
-	loaderFuncs map[reflect.Type]func(interface{}, map[tagKey]string) (reflect.Value, error)
+	// the loaders, types, and validators registered so far.  Readers load it without locking.
+	// Writers hold mu, and replace it with an updated copy.
+	reg    atomic.Pointer[argRegistry]
+	mu     sync.Mutex
+	frozen bool
+
+	// held while building input objects, so that two goroutines don't both build one.
+	typesMu sync.Mutex
+
+	// the input objects built so far, by their GraphQL names.  Unlike the registry's cache, it's
+	// kept when something is registered, so that a struct is always described by the same object.
+	// Guarded by typesMu.
+	namedInputs map[string]namedInput
+
+	// a copy of the loader that reads json tags, for RegisterInputType.
+	jsonView atomic.Pointer[jsonView]
+
+	// when true, required arguments are not wrapped in graphql.NonNull.
+	allowNullRequired atomic.Bool
+
+	// the struct tag syntax, which can be changed with Options.
+	tag        string
+	descTag    string
+	separator  string
+	assignor   string
+	nameMapper func(string) string
+
+	// builds the types of arguments like Where.  nil means the default type builder.
+	typeBuilder *TypeBuilder
+}
+
+// types returns the TypeBuilder for the types of arguments like Where.
+func (e *ArgLoader) types() *TypeBuilder {
+	if e.typeBuilder == nil {
+		return defaultTypeBuilder
+	}
+	return e.typeBuilder
+}
 
-	// a map from reflect types to the graphql types that should be used for their arguments.
-	gqlTypes map[reflect.Type]graphql.Output
+// AllowNullRequired controls whether SafeArgsConfig describes required arguments with nullable
+// GraphQL types, as it did before they were marked NonNull.  LoadArgs enforces required arguments
+// either way.
+func (e *ArgLoader) AllowNullRequired(allow bool) {
+	if e.allowNullRequired.Swap(allow) != allow {
+		// input objects built before describe their fields the old way.
+		e.reset()
+	}
 }
 
 // ArgsConfig takes a struct instance with appropriate struct tags on its fields and returns a map
@@ -102,29 +191,46 @@ func (e *ArgLoader) ArgsConfig(i interface{}) graphql.FieldConfigArgument {
 	return conf
 }
 
-func readTag(field reflect.StructField) (string, map[tagKey]string, bool) {
-	v, ok := field.Tag.Lookup(defaultTag)
+// TagOptions holds the options given after the argument name in an arg tag.  For
+// `arg:"since,required,layout:2006-01-02"`, it holds "required" with an empty value and "layout"
+// with the value "2006-01-02".  Loader funcs may accept it as a second argument to be told about the
+// options on the field they're loading.
+type TagOptions map[string]string
+
+// Has reports whether the option was given, with or without a value.
+func (o TagOptions) Has(key string) bool {
+	_, ok := o[key]
+	return ok
+}
+
+// Get returns the value of the option, or an empty string if it was not given.
+func (o TagOptions) Get(key string) string {
+	return o[key]
+}
+
+func (e *ArgLoader) readTag(field reflect.StructField) (string, TagOptions, bool) {
+	v, ok := field.Tag.Lookup(e.tag)
 	if !ok {
 		// this field doesn't have our tag.  Skip.
 		return "", nil, false
 	}
-	values := strings.Split(v, defaultSeparator)
+	values := strings.Split(v, e.separator)
 	if len(values) < 1 || values[0] == defaultHidden {
 		return "", nil, false
 	}
 	name := values[0]
 	if name == "" {
-		name = field.Name
+		name = e.nameMapper(field.Name)
 	}
-	config := map[tagKey]string{}
-	for _, value := range values {
-		keyValuePair := strings.SplitN(value, defaultAssignor, 2)
+	config := TagOptions{}
+	for _, value := range values[1:] {
+		keyValuePair := strings.SplitN(value, e.assignor, 2)
 		if len(keyValuePair) < 1 {
 			return "", nil, false
 		} else if len(keyValuePair) < 2 {
-			config[tagKey(keyValuePair[0])] = ""
+			config[keyValuePair[0]] = ""
 		} else {
-			config[tagKey(keyValuePair[0])] = keyValuePair[1]
+			config[keyValuePair[0]] = keyValuePair[1]
 		}
 	}
 	return name, config, true
@@ -146,82 +252,387 @@ func (e *ArgLoader) SafeArgsConfig(i interface{}) (graphql.FieldConfigArgument,
 	if structType.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("%v is not a struct", i)
 	}
+	// non-zero field values on the instance are used as argument defaults.  A nil pointer has none.
+	structVal := reflect.Indirect(reflect.ValueOf(i))
+
+	e.typesMu.Lock()
+	defer e.typesMu.Unlock()
 
+	p, err := e.plan(structType)
+	if err != nil {
+		return nil, err
+	}
 	out := graphql.FieldConfigArgument{}
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		argName, _, ok := readTag(field)
-		if !ok {
-			// this field doesn't have our tag.  Skip.
-			continue
+	for _, fp := range p.fields {
+		var instance reflect.Value
+		if structVal.IsValid() {
+			// fields promoted through a nil embedded pointer have no instance value.
+			instance, _ = structVal.FieldByIndexErr(fp.index)
+		}
+		argType, defaultValue, err := e.fieldArg(fp.field, fp.name, fp.config, instance)
+		if err != nil {
+			return nil, err
+		}
+		out[fp.name] = &graphql.ArgumentConfig{
+			Type:         argType,
+			DefaultValue: defaultValue,
+			Description:  fp.desc,
 		}
+	}
+	return out, nil
+}
 
-		if argType, ok := e.gqlTypes[field.Type]; ok {
-			out[argName] = &graphql.ArgumentConfig{
-				Type:        argType,
-				Description: field.Tag.Get(descTag),
-			}
+// fieldArg returns the GraphQL type and default value for the argument read from a struct field,
+// taking the options in its tag into account.  If instance is a valid, non-zero value, it's used as
+// the default when the tag doesn't provide one.
+func (e *ArgLoader) fieldArg(field reflect.StructField, argName string, config TagOptions, instance reflect.Value) (graphql.Input, interface{}, error) {
+	argType, err := e.argType(field.Type, argName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var defaultValue interface{}
+	if s, ok := config[tagKeyDefault]; ok {
+		defaultValue, err = e.parseDefault(s, field.Type, argType, config)
+	} else if instance.IsValid() && !instance.IsZero() {
+		if v, ok := optionalValue(instance); ok {
+			defaultValue, err = e.instanceDefault(v, argType, config)
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", argName, err)
+	}
+
+	// an argument with a default can always be left out, and an Optional can always be null.
+	_, optional := optionalElem(field.Type)
+	if _, ok := config[tagKeyRequired]; ok && !e.allowNullRequired.Load() && defaultValue == nil && !optional {
+		return graphql.NewNonNull(argType), nil, nil
+	}
+	return argType, defaultValue, nil
+}
+
+// leafType is implemented by the GraphQL scalar and enum types, which are the only ones we can turn
+// a default value into.
+type leafType interface {
+	graphql.Input
+	Serialize(value interface{}) interface{}
+	ParseValue(value interface{}) interface{}
+}
+
+// parseDefault converts the default from a struct tag into the value GraphQL will supply for the
+// argument when it's absent, and checks that the loader for t accepts it.
+func (e *ArgLoader) parseDefault(s string, t reflect.Type, argType graphql.Input, config TagOptions) (interface{}, error) {
+	leaf, ok := argType.(leafType)
+	if !ok {
+		return nil, fmt.Errorf("cannot set a default for a %v argument", argType)
+	}
+	parsed := leaf.ParseValue(s)
+	if parsed == nil {
+		return nil, fmt.Errorf("default %q is not a valid %v", s, leaf)
+	}
+	if err := e.checkDefault(parsed, t, config); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// instanceDefault converts a field value from the struct instance given to SafeArgsConfig into an
+// argument default.  Only scalar and enum fields are used; others are ignored.
+func (e *ArgLoader) instanceDefault(v reflect.Value, argType graphql.Input, config TagOptions) (interface{}, error) {
+	leaf, ok := argType.(leafType)
+	if !ok {
+		return nil, nil
+	}
+	parsed := leaf.ParseValue(leaf.Serialize(reflect.Indirect(v).Interface()))
+	if parsed == nil {
+		return nil, fmt.Errorf("cannot use %v as a default %v", v.Interface(), leaf)
+	}
+	if err := e.checkDefault(parsed, v.Type(), config); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// checkDefault runs a default value through the loader for t, so that bad defaults are reported
+// when the schema is built instead of when a query leaves the argument out.
+func (e *ArgLoader) checkDefault(i interface{}, t reflect.Type, config TagOptions) error {
+	valErrs := ArgErrors{}
+	v, err := e.compileLoader(t, config)(i, "default", &valErrs)
+	if err != nil {
+		return err
+	}
+	if !v.IsValid() {
+		return fmt.Errorf("default %v cannot be loaded as %v", i, t)
+	}
+	return nil
+}
+
+// argType returns the GraphQL type to be used for arguments of type t.  Types without a registered
+// loader may describe themselves by implementing ArgUnmarshaler.  Otherwise, slices and arrays
+// become lists of their element type, and struct types are described as input objects.  The caller
+// must hold typesMu.
+func (e *ArgLoader) argType(t reflect.Type, argName string) (graphql.Input, error) {
+	if argType, ok := e.reg.Load().gqlTypes[t]; ok {
+		return argType, nil
+	}
+	if argType, ok, err := e.unmarshalerArgType(t); ok {
+		if err != nil {
+			return nil, err
+		}
+		if err := checkArgType(t, argType, t.String()+".GraphQLInputType"); err != nil {
+			return nil, err
+		}
+		return argType, nil
+	}
+	if elem, ok := optionalElem(t); ok {
+		return e.argType(elem, argName)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return e.argType(t.Elem(), argName)
+	case reflect.Slice, reflect.Array:
+		elemType, err := e.argType(t.Elem(), argName)
+		if err != nil {
+			return nil, err
+		}
+		return graphql.NewList(elemType), nil
+	case reflect.Struct:
+		return e.inputObject(t, argName)
+	}
+	return nil, fmt.Errorf("no argument loader registered for %v type", t)
+}
+
+// expectedType names the GraphQL type already known for arguments of type t, for error messages.
+// Unlike argType, it never builds new types.
+func (e *ArgLoader) expectedType(t reflect.Type) string {
+	if argType, ok := e.reg.Load().gqlTypes[t]; ok {
+		return argType.String()
+	}
+	if argType, ok, err := e.unmarshalerArgType(t); ok {
+		if err != nil {
+			return ""
+		}
+		return argType.String()
+	}
+	if elem, ok := optionalElem(t); ok {
+		return e.expectedType(elem)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return e.expectedType(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if elem := e.expectedType(t.Elem()); elem != "" {
+			return "[" + elem + "]"
+		}
+	case reflect.Struct:
+		if obj, ok := e.reg.Load().inputObjects.Load(t); ok {
+			return obj.(*graphql.InputObject).Name()
+		}
+	}
+	return ""
+}
+
+// inputObject builds a GraphQL input object from the tagged fields of a struct type.  The caller
+// must hold typesMu.
+func (e *ArgLoader) inputObject(t reflect.Type, argName string) (*graphql.InputObject, error) {
+	inputObjects := e.reg.Load().inputObjects
+	if obj, ok := inputObjects.Load(t); ok {
+		return obj.(*graphql.InputObject), nil
+	}
+
+	name := t.Name()
+	if name == "" {
+		// anonymous structs get named after the argument that holds them.
+		name = argName
+	}
+	name = strings.ToUpper(name[:1]) + name[1:] + "Input"
+
+	p, err := e.plan(t)
+	if err != nil {
+		return nil, err
+	}
+	if e.namedInputs == nil {
+		e.namedInputs = map[string]namedInput{}
+	}
+	named, ok := e.namedInputs[name]
+	refill := false
+	switch {
+	case !ok:
+		// graphql-go reads the field map lazily, so we can remember the object before filling in
+		// its fields.  That lets self-referencing structs point back at it.
+		named = namedInput{t: t, fields: graphql.InputObjectConfigFieldMap{}}
+		named.obj = graphql.NewInputObject(graphql.InputObjectConfig{
+			Name:   name,
+			Fields: named.fields,
+		})
+		e.namedInputs[name] = named
+	case named.t != t:
+		return nil, fmt.Errorf("cannot describe %v with input object %s, which already describes %v", t, name, named.t)
+	default:
+		// the object was built before something was registered, and a schema may already hold it.
+		// Fill it in again rather than making a second object with the same name.
+		for fieldName := range named.fields {
+			delete(named.fields, fieldName)
+		}
+		refill = true
+	}
+	inputObjects.Store(t, named.obj)
+
+	for _, fp := range p.fields {
+		fieldType, defaultValue, err := e.fieldArg(fp.field, fp.name, fp.config, reflect.Value{})
+		if err != nil {
+			inputObjects.Delete(t)
+			return nil, fmt.Errorf("%s.%s: %v", t, fp.field.Name, err)
+		}
+		field := &graphql.InputObjectFieldConfig{
+			Type:         fieldType,
+			DefaultValue: defaultValue,
+			Description:  fp.desc,
+		}
+		if refill {
+			// graphql-go keeps the fields it has already read, and AddFieldConfig reads them again.
+			named.obj.AddFieldConfig(fp.name, field)
 		} else {
-			return nil, fmt.Errorf("no argument loader registered for %v type", field.Type)
+			named.fields[fp.name] = field
 		}
+	}
+	return named.obj, nil
+}
+
+// a namedInput is an input object built for a struct type, along with the field map it reads.
+type namedInput struct {
+	t      reflect.Type
+	obj    *graphql.InputObject
+	fields graphql.InputObjectConfigFieldMap
+}
 
+// RegisterArgParser takes a func (interface{}) (<anytype>, error) and registers it on the ArgLoader
+// as the parser for <anytype>.  The func may also be a func (interface{}, TagOptions) (<anytype>,
+// error), in which case it's passed the options from the tag of the field being loaded.
+//
+// gqlType must be a type that GraphQL accepts for arguments: a scalar, enum, or input object, or a
+// list or non-null of one.  It used to be a graphql.Output; since graphql-go's Input and Output
+// interfaces have the same methods, existing callers that pass an Output still compile.
+func (e *ArgLoader) RegisterArgParser(f interface{}, gqlType graphql.Input) error {
+	t, loader, fname, err := wrapArgParser(f)
+	if err != nil {
+		return err
 	}
-	return out, nil
+	return e.register(t, loader, gqlType, fname)
 }
 
-// RegisterArgParser takes a func (string) (<anytype>, error) and registers it on the ArgLoader as
-// the parser for <anytype>
-func (e *ArgLoader) RegisterArgParser(f interface{}, gqlType graphql.Output) error {
+// Override is like RegisterArgParser, but replaces any loader func already registered for the type
+// that f returns.
+func (e *ArgLoader) Override(f interface{}, gqlType graphql.Input) error {
+	t, loader, fname, err := wrapArgParser(f)
+	if err != nil {
+		return err
+	}
+	if err := checkArgType(t, gqlType, fname); err != nil {
+		return err
+	}
+	return e.update(func(reg *argRegistry) error {
+		reg.loaderFuncs[t] = loader
+		reg.gqlTypes[t] = gqlType
+		return nil
+	})
+}
+
+// wrapArgParser checks that f is a loader func, and wraps it as a loaderFunc for the type it
+// returns.  fname identifies f in error messages.
+func wrapArgParser(f interface{}) (t reflect.Type, loader loaderFunc, fname string, err error) {
 	// alright, let's inspect this f and make sure it's a func (string) (sometype, err)
-	t := reflect.TypeOf(f)
-	if t.Kind() != reflect.Func {
-		return fmt.Errorf("%v is not a func", f)
+	ft := reflect.TypeOf(f)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return nil, nil, "", fmt.Errorf("%v is not a func", f)
 	}
 
-	fname := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
-	// f should accept one argument
-	if t.NumIn() != 1 {
-		return fmt.Errorf(
-			"loader func should accept 1 interface{} argument. %v accepts %d arguments",
-			fname, t.NumIn())
+	fname = runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	// f should accept one argument, and optionally the tag options
+	if ft.NumIn() != 1 && ft.NumIn() != 2 {
+		return nil, nil, "", fmt.Errorf(
+			"loader func should accept 1 interface{} argument and optionally TagOptions. %v accepts %d arguments",
+			fname, ft.NumIn())
+	}
+	withOptions := ft.NumIn() == 2
+	if withOptions && ft.In(1) != reflect.TypeOf(TagOptions{}) {
+		return nil, nil, "", fmt.Errorf(
+			"loader func's second argument should be TagOptions. %s's second argument is %v",
+			fname, ft.In(1))
 	}
 	// it should return two things
-	if t.NumOut() != 2 {
-		return fmt.Errorf(
+	if ft.NumOut() != 2 {
+		return nil, nil, "", fmt.Errorf(
 			"loader func should return 2 arguments. %v returns %d arguments",
-			fname, t.NumOut())
+			fname, ft.NumOut())
 	}
 	// the first can be any type. the second should be error
 	errorInterface := reflect.TypeOf((*error)(nil)).Elem()
-	if !t.Out(1).Implements(errorInterface) {
-		return fmt.Errorf(
+	if !ft.Out(1).Implements(errorInterface) {
+		return nil, nil, "", fmt.Errorf(
 			"loader func's last return value should be error. %s's last return value is %v",
-			fname, t.Out(1))
+			fname, ft.Out(1))
 	}
-	_, alreadyRegistered := e.loaderFuncs[t.Out(0)]
-	if alreadyRegistered {
-		return fmt.Errorf("a loader func has already been registered for the %v type.  cannot also register %s",
-			t.Out(0), fname,
-		)
+
+	// the loaders that ship with this package can be called without reflection.
+	if loader, ok := directLoader(f, fname); ok {
+		return ft.Out(0), loader, fname, nil
 	}
 
 	callable := reflect.ValueOf(f)
-	wrapped := func(i interface{}, config map[tagKey]string) (v reflect.Value, err error) {
+	wrapped := func(i interface{}, config TagOptions) (v reflect.Value, err error) {
 		defer func() {
 			if p := recover(); p != nil {
 				// we panicked running the inner loader func.
 				err = fmt.Errorf("%s panicked: %s", fname, p)
 			}
 		}()
-		returnvals := callable.Call([]reflect.Value{reflect.ValueOf(i)})
+		// reflect can't call f with an untyped nil.
+		arg := reflect.ValueOf(i)
+		if i == nil {
+			arg = reflect.Zero(ft.In(0))
+		}
+		in := []reflect.Value{arg}
+		if withOptions {
+			in = append(in, reflect.ValueOf(config))
+		}
+		returnvals := callable.Call(in)
 		// check for non nil error
 		if !returnvals[1].IsNil() {
 			return reflect.Value{}, fmt.Errorf("%v", returnvals[1])
 		}
 		return returnvals[0], nil
 	}
-	e.loaderFuncs[t.Out(0)] = wrapped
-	e.gqlTypes[t.Out(0)] = gqlType
+	return ft.Out(0), wrapped, fname, nil
+}
+
+// register stores a wrapped loader func and the GraphQL type for arguments of type t.  name
+// identifies the loader in error messages.
+func (e *ArgLoader) register(t reflect.Type, loader loaderFunc, gqlType graphql.Input, name string) error {
+	if err := checkArgType(t, gqlType, name); err != nil {
+		return err
+	}
+	return e.update(func(reg *argRegistry) error {
+		if _, alreadyRegistered := reg.loaderFuncs[t]; alreadyRegistered {
+			return fmt.Errorf("a loader func has already been registered for the %v type.  cannot also register %s",
+				t, name,
+			)
+		}
+		reg.loaderFuncs[t] = loader
+		reg.gqlTypes[t] = gqlType
+		return nil
+	})
+}
+
+// checkArgType checks that gqlType can be used for arguments of type t.  name identifies the loader
+// or type that provided gqlType in error messages.
+func checkArgType(t reflect.Type, gqlType graphql.Input, name string) error {
+	if v := reflect.ValueOf(gqlType); gqlType == nil || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return fmt.Errorf("cannot use %s for the %v type: no GraphQL type given", name, t)
+	}
+	if !graphql.IsInputType(gqlType) {
+		return fmt.Errorf("cannot use %s for the %v type: %v is not a GraphQL input type", name, t, gqlType)
+	}
 	return nil
 }
 
@@ -239,38 +650,59 @@ func (e *ArgLoader) LoadArgs(p graphql.ResolveParams, c interface{}) error {
 		return fmt.Errorf("%v is not a struct", c)
 	}
 
-	valErrs := multierror.Append(nil)
-	for i := 0; i < cType.NumField(); i++ {
-		field := cType.Field(i)
+	valErrs := ArgErrors{}
+	args := resolveUploads(p.Context, explicitNulls(p))
+	if err := e.loadStruct(args, cVal, "", &valErrs); err != nil {
+		return err
+	}
+	return valErrs.errorOrNil()
+}
 
-		argName, config, ok := readTag(field)
-		if !ok {
-			// this field doesn't have our tag.  Skip.
-			continue
-		}
+// loadStruct sets the tagged fields of the struct value v from the args map.  Problems with the
+// provided values are appended to valErrs, with names prefixed by prefix.  A returned error means
+// the struct itself cannot be loaded.
+func (e *ArgLoader) loadStruct(args map[string]interface{}, v reflect.Value, prefix string, valErrs *ArgErrors) error {
+	p, err := e.plan(v.Type())
+	if err != nil {
+		return err
+	}
+	return e.loadPlan(p, args, v, prefix, valErrs)
+}
 
-		interfaceVal, ok := p.Args[argName]
-		if !ok {
-			// could not find the key we're looking for in map.  is it required?
-			if _, ok := config[tagKeyRequired]; ok {
-				multierror.Append(valErrs, fmt.Errorf("%s is required", argName))
-			}
-			continue
+// loadPlan does the work of loadStruct, with the struct's plan.
+func (e *ArgLoader) loadPlan(p *structPlan, args map[string]interface{}, v reflect.Value, prefix string, valErrs *ArgErrors) error {
+	for _, fp := range p.fields {
+		path := prefix + fp.name
+
+		interfaceVal, ok := args[fp.name]
+		if interfaceVal == nil && !fp.nullable {
+			// only some types can tell a null from a missing argument.
+			ok = false
 		}
-		loaderFunc, ok := e.loaderFuncs[field.Type]
 		if !ok {
-			return fmt.Errorf("no loader function found for type %v", field.Type)
+			if fp.hasDefault {
+				// fall back to the default from the tag.
+				var err error
+				if interfaceVal, err = fp.tagDefault(e); err != nil {
+					return fmt.Errorf("%s: %v", path, err)
+				}
+			} else {
+				// could not find the key we're looking for in map.  is it required?
+				if fp.required {
+					valErrs.add(newArgError(ErrCodeRequired, path, fp.field.Type, e.expectedType(fp.field.Type), nil))
+				}
+				continue
+			}
 		}
 
-		toSet, err := loaderFunc(interfaceVal, config)
+		toSet, err := fp.load(interfaceVal, path, valErrs)
 		if err != nil {
-			if _, ok := config[tagKeyCoalesceZero]; !ok {
-				valErrs = multierror.Append(valErrs, fmt.Errorf("%s is not valid", argName))
-				continue
-			}
-			toSet = reflect.Zero(field.Type)
+			return err
+		}
+		if toSet.IsValid() {
+			settableField(v, fp.index).Set(toSet)
+			fp.validate(toSet, path, e, valErrs)
 		}
-		cVal.Field(i).Set(toSet)
 	}
-	return valErrs.ErrorOrNil()
+	return nil
 }
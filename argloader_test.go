@@ -0,0 +1,480 @@
+package sugar
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAddress struct {
+	Street string `arg:"street"`
+	Zip    string `arg:"zip,required"`
+}
+
+type testNestedArgs struct {
+	Name    string       `arg:"name"`
+	Address testAddress  `arg:"address" desc:"Where to send things."`
+	Billing *testAddress `arg:"billing"`
+}
+
+func TestSafeArgsConfigNested(t *testing.T) {
+	conf, err := SafeArgsConfig(testNestedArgs{})
+	assert.Nil(t, err)
+
+	addr, ok := conf["address"].Type.(*graphql.InputObject)
+	if assert.True(t, ok) {
+		assert.Equal(t, "TestAddressInput", addr.Name())
+		assert.Equal(t, "String!", addr.Fields()["zip"].Type.String())
+	}
+	assert.Equal(t, "Where to send things.", conf["address"].Description)
+	// the same struct is described by the same input object.
+	assert.Equal(t, conf["address"].Type, conf["billing"].Type)
+}
+
+func TestLoadArgsNested(t *testing.T) {
+	args := testNestedArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name":    "bob",
+		"address": map[string]interface{}{"street": "1 Main St", "zip": "84101"},
+		"billing": map[string]interface{}{"zip": "84102"},
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testNestedArgs{
+		Name:    "bob",
+		Address: testAddress{Street: "1 Main St", Zip: "84101"},
+		Billing: &testAddress{Zip: "84102"},
+	}, args)
+}
+
+func TestLoadArgsNestedErrors(t *testing.T) {
+	args := testNestedArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"address": map[string]interface{}{"street": "1 Main St"},
+		"billing": map[string]interface{}{"zip": 5},
+	}}, &args)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "address.zip is required")
+		assert.Contains(t, err.Error(), "billing.zip is not valid")
+	}
+}
+
+type testListArgs struct {
+	Movies  []string      `arg:"favoriteMovies"`
+	Ratings [][]int       `arg:"ratings"`
+	Pair    [2]float64    `arg:"pair"`
+	Stops   []testAddress `arg:"stops"`
+}
+
+func TestSafeArgsConfigLists(t *testing.T) {
+	conf, err := SafeArgsConfig(testListArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, "[String]", conf["favoriteMovies"].Type.String())
+	assert.Equal(t, "[[Int]]", conf["ratings"].Type.String())
+	assert.Equal(t, "[Float]", conf["pair"].Type.String())
+	assert.Equal(t, "[TestAddressInput]", conf["stops"].Type.String())
+}
+
+func TestLoadArgsLists(t *testing.T) {
+	args := testListArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"favoriteMovies": []interface{}{"Heat", "Ronin"},
+		"ratings":        []interface{}{[]interface{}{1, 2}, []interface{}{}},
+		"pair":           []interface{}{1.5, 2.5},
+		"stops":          []interface{}{map[string]interface{}{"zip": "84101"}},
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testListArgs{
+		Movies:  []string{"Heat", "Ronin"},
+		Ratings: [][]int{{1, 2}, {}},
+		Pair:    [2]float64{1.5, 2.5},
+		Stops:   []testAddress{{Zip: "84101"}},
+	}, args)
+}
+
+func TestLoadArgsListErrors(t *testing.T) {
+	args := testListArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"favoriteMovies": []interface{}{"Heat", "Ronin", "Alien", 4},
+		"pair":           []interface{}{1.5, 2.5, 3.5},
+		"stops":          []interface{}{map[string]interface{}{}},
+	}}, &args)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "favoriteMovies[3] is not valid")
+		assert.Contains(t, err.Error(), "pair has more than 2 items")
+		assert.Contains(t, err.Error(), "stops[0].zip is required")
+	}
+}
+
+type testRequiredArgs struct {
+	ID   string `arg:"id,required"`
+	Name string `arg:"name"`
+}
+
+func TestSafeArgsConfigRequired(t *testing.T) {
+	conf, err := SafeArgsConfig(testRequiredArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, "String!", conf["id"].Type.String())
+	assert.Equal(t, "String", conf["name"].Type.String())
+
+	loader, err := New()
+	assert.Nil(t, err)
+	loader.AllowNullRequired(true)
+	conf, err = loader.SafeArgsConfig(testRequiredArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, "String", conf["id"].Type.String())
+
+	// LoadArgs keeps enforcing required arguments.
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{}}, &testRequiredArgs{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "id is required")
+	}
+}
+
+type testDefaultArgs struct {
+	Limit  int     `arg:"limit,default:20"`
+	Query  string  `arg:"query,required,default:*"`
+	Offset int     `arg:"offset"`
+	Scale  float64 `arg:"scale"`
+}
+
+func TestSafeArgsConfigDefaults(t *testing.T) {
+	conf, err := SafeArgsConfig(testDefaultArgs{Scale: 1.5})
+	assert.Nil(t, err)
+	assert.Equal(t, 20, conf["limit"].DefaultValue)
+	assert.Equal(t, "*", conf["query"].DefaultValue)
+	assert.Equal(t, "String", conf["query"].Type.String())
+	assert.Nil(t, conf["offset"].DefaultValue)
+	assert.Equal(t, 1.5, conf["scale"].DefaultValue)
+
+	_, err = SafeArgsConfig(struct {
+		Limit int `arg:"limit,default:lots"`
+	}{})
+	assert.NotNil(t, err)
+}
+
+func TestLoadArgsDefaults(t *testing.T) {
+	args := testDefaultArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"offset": 40,
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testDefaultArgs{Limit: 20, Query: "*", Offset: 40}, args)
+}
+
+type testValidatedArgs struct {
+	Name  string   `arg:"name,min:1,max:5"`
+	Limit int      `arg:"limit,min:1,max:500"`
+	Code  string   `arg:"code,len:3,pattern:^[A-Z]+$"`
+	Color string   `arg:"color,oneof:red|green|blue"`
+	Email string   `arg:"email,email"`
+	Tags  []string `arg:"tags,nonempty"`
+	Slug  string   `arg:"slug,slug"`
+}
+
+func TestLoadArgsValidation(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	err = loader.RegisterValidator("slug", func(v interface{}, param string) error {
+		if v.(string) != "a-slug" {
+			return errors.New("must be a slug")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	args := testValidatedArgs{}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name":  "Bob",
+		"limit": 20,
+		"code":  "ABC",
+		"color": "red",
+		"email": "bob@example.com",
+		"tags":  []interface{}{"a"},
+		"slug":  "a-slug",
+	}}, &args)
+	assert.Nil(t, err)
+
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name":  "Robert",
+		"limit": 501,
+		"code":  "abcd",
+		"color": "mauve",
+		"email": "Bob <bob@example.com>",
+		"tags":  []interface{}{},
+		"slug":  "A Slug",
+	}}, &args)
+	if assert.NotNil(t, err) {
+		for _, msg := range []string{
+			"name must have a length of at most 5",
+			"limit must be at most 500",
+			"code must have a length of 3",
+			"code must match ^[A-Z]+$",
+			"color must be one of red, green, blue",
+			"email must be an email address",
+			"tags must not be empty",
+			"slug must be a slug",
+		} {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+}
+
+type testValidatedNullArgs struct {
+	Count sql.NullInt64  `arg:"count,max:5"`
+	Name  sql.NullString `arg:"name,nonempty"`
+	Nick  null.String    `arg:"nick,pattern:^[a-z]+$"`
+}
+
+func TestLoadArgsValidationNullWrappers(t *testing.T) {
+	args := testValidatedNullArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"count": 99,
+		"name":  "",
+		"nick":  "Bob",
+	}}, &args)
+	if assert.NotNil(t, err) {
+		for _, msg := range []string{
+			"count must be at most 5",
+			"name must not be empty",
+			"nick must match ^[a-z]+$",
+		} {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"count": 3,
+		"name":  "Bob",
+		"nick":  "bob",
+	}}, &args)
+	assert.Nil(t, err)
+}
+
+func TestLoadArgsErrors(t *testing.T) {
+	args := testNestedArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name":    5,
+		"address": map[string]interface{}{},
+	}}, &args)
+	argErrs, ok := err.(ArgErrors)
+	if !assert.True(t, ok) || !assert.Len(t, argErrs, 2) {
+		return
+	}
+
+	assert.Equal(t, "name is not valid: 5 is not a string", argErrs[0].Error())
+	assert.Equal(t, ErrCodeInvalidType, argErrs[0].Code)
+	assert.Equal(t, "String", argErrs[0].ExpectedType)
+
+	assert.Equal(t, "address", argErrs[1].Arg)
+	assert.Equal(t, map[string]interface{}{
+		"code":         "REQUIRED",
+		"argument":     "address",
+		"path":         "address.zip",
+		"message":      "address.zip is required",
+		"goType":       "string",
+		"expectedType": "String",
+	}, argErrs[1].Extensions())
+
+	ext := argErrs.Extensions()
+	assert.Equal(t, "BAD_ARGUMENTS", ext["code"])
+	assert.Len(t, ext["arguments"], 2)
+}
+
+type testCode string
+
+type testTagOptionsArgs struct {
+	Name  string    `arg:"name,trim"`
+	Since time.Time `arg:"since,layout:2006-01-02,tz:America/Denver"`
+	Code  testCode  `arg:"code,prefix:X-"`
+}
+
+func TestLoadArgsTagOptions(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	loadCode := func(i interface{}, opts TagOptions) (testCode, error) {
+		s, ok := i.(string)
+		if !ok {
+			return "", errors.New("not a string")
+		}
+		return testCode(opts.Get("prefix") + s), nil
+	}
+	assert.Nil(t, loader.RegisterArgParser(loadCode, graphql.String))
+
+	args := testTagOptionsArgs{}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name":  "  bob ",
+		"since": "2020-03-04",
+		"code":  "12",
+	}}, &args)
+	assert.Nil(t, err)
+	denver, _ := time.LoadLocation("America/Denver")
+	assert.Equal(t, testTagOptionsArgs{
+		Name:  "bob",
+		Since: time.Date(2020, 3, 4, 0, 0, 0, 0, denver),
+		Code:  "X-12",
+	}, args)
+}
+
+func TestRegisterArgParserInputType(t *testing.T) {
+	loadCode := func(i interface{}) (testCode, error) {
+		return testCode(i.(string)), nil
+	}
+	object := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Code",
+		Fields: graphql.Fields{"code": &graphql.Field{Type: graphql.String}},
+	})
+
+	loader, err := New()
+	assert.Nil(t, err)
+	err = loader.RegisterArgParser(loadCode, object)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "sugar.testCode type: Code is not a GraphQL input type")
+		assert.Contains(t, err.Error(), "TestRegisterArgParserInputType")
+	}
+	assert.NotNil(t, loader.RegisterArgParser(loadCode, graphql.NewList(object)))
+	assert.NotNil(t, loader.RegisterArgParser(loadCode, nil))
+	assert.NotNil(t, loader.Override(loadCode, object))
+
+	// an Output that is also an input type still works.
+	var output graphql.Output = graphql.String
+	assert.Nil(t, loader.RegisterArgParser(loadCode, output))
+}
+
+type TestPagination struct {
+	Limit  int `arg:"limit,default:20"`
+	Offset int `arg:"offset"`
+}
+
+type TestTenant struct {
+	Tenant string `arg:"tenant,required"`
+	Name   string `arg:"name"`
+}
+
+type testEmbeddedArgs struct {
+	TestPagination
+	*TestTenant
+	Name string `arg:"name"`
+}
+
+func TestEmbeddedArgs(t *testing.T) {
+	conf, err := SafeArgsConfig(testEmbeddedArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 4)
+	assert.Equal(t, 20, conf["limit"].DefaultValue)
+	assert.Equal(t, "String!", conf["tenant"].Type.String())
+
+	args := testEmbeddedArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"offset": 40,
+		"tenant": "acme",
+		"name":   "bob",
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testEmbeddedArgs{
+		TestPagination: TestPagination{Limit: 20, Offset: 40},
+		TestTenant:     &TestTenant{Tenant: "acme"},
+		Name:           "bob",
+	}, args)
+
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{}}, &testEmbeddedArgs{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "tenant is required")
+	}
+}
+
+type testUnexportedPaging struct {
+	First int `arg:"first"`
+}
+
+type testUnexportedEmbeddedArgs struct {
+	*testUnexportedPaging
+	testUnexportedTenant
+	Name string `arg:"name"`
+}
+
+type testUnexportedTenant struct {
+	Tenant string `arg:"tenant"`
+}
+
+func TestEmbeddedUnexportedPointer(t *testing.T) {
+	// the unexported pointer is skipped, but an unexported struct embedded by value still works.
+	conf, err := SafeArgsConfig(testUnexportedEmbeddedArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 2)
+	assert.NotContains(t, conf, "first")
+
+	args := testUnexportedEmbeddedArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"first":  10,
+		"tenant": "acme",
+		"name":   "bob",
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Nil(t, args.testUnexportedPaging)
+	assert.Equal(t, "acme", args.Tenant)
+	assert.Equal(t, "bob", args.Name)
+}
+
+// embedding cycles go through pointers, and unexported ones are skipped anyway, so these types are
+// exported.
+type SelfEmbeddingArgs struct {
+	*SelfEmbeddingArgs
+	X int `arg:"x"`
+}
+
+type EmbedsB struct {
+	*EmbedsA
+	B int `arg:"b"`
+}
+
+type EmbedsA struct {
+	*EmbedsB
+	A int `arg:"a"`
+}
+
+func TestEmbeddingCycle(t *testing.T) {
+	// a struct's own type is skipped where it embeds itself, directly or through another struct.
+	conf, err := SafeArgsConfig(SelfEmbeddingArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 1)
+	assert.Contains(t, conf, "x")
+
+	conf, err = SafeArgsConfig(EmbedsA{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 2)
+	assert.Contains(t, conf, "a")
+	assert.Contains(t, conf, "b")
+
+	args := EmbedsA{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"a": 1, "b": 2}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, args.A)
+	assert.Equal(t, 2, args.B)
+}
+
+// vet refuses json tags on unexported fields, but the json view that RegisterInputType loads with
+// plans fields the same way as arg tags.
+type testUnexportedFieldArgs struct {
+	Name   string `arg:"name"`
+	secret string `arg:"secret"`
+}
+
+func TestUnexportedField(t *testing.T) {
+	conf, err := SafeArgsConfig(testUnexportedFieldArgs{})
+	assert.Nil(t, err)
+	assert.NotContains(t, conf, "secret")
+
+	// an unexported field is left alone even if its key is given.
+	args := testUnexportedFieldArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name":   "bob",
+		"secret": "hunter2",
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testUnexportedFieldArgs{Name: "bob"}, args)
+}
@@ -1,25 +1,31 @@
 package sugar
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/btubbs/datetime"
 	"github.com/btubbs/pqjson"
 	"github.com/guregu/null"
+	"github.com/lib/pq"
 )
 
-// LoadUInt loads `uint` from graphql arg
+// LoadUInt loads `uint` from a Long graphql arg, since GraphQL's Int can't hold all of them.
 func LoadUInt(i interface{}) (uint, error) {
-	stringified := fmt.Sprintf("%v", i)
-	wd, err := strconv.ParseUint(stringified, 10, 32)
+	s, err := loadLong(i)
 	if err != nil {
-		return 0, fmt.Errorf("%v is not an unsigned integer", i)
+		return 0, err
 	}
-
-	return uint(wd), nil
+	n, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	if err != nil {
+		return 0, fmt.Errorf("%s is out of range for uint", s)
+	}
+	return uint(n), nil
 }
 
 // LoadBool loads `bool` from graphql arg
@@ -52,6 +58,19 @@ func LoadString(i interface{}) (string, error) {
 	return b, nil
 }
 
+// LoadStringWithOptions loads `string` from graphql arg.  With the "trim" tag option, leading and
+// trailing white space is removed.
+func LoadStringWithOptions(i interface{}, opts TagOptions) (string, error) {
+	s, err := LoadString(i)
+	if err != nil {
+		return "", err
+	}
+	if opts.Has("trim") {
+		s = strings.TrimSpace(s)
+	}
+	return s, nil
+}
+
 // LoadInt loads `int` from graphql arg
 func LoadInt(i interface{}) (int, error) {
 	b, ok := i.(int)
@@ -61,22 +80,95 @@ func LoadInt(i interface{}) (int, error) {
 	return b, nil
 }
 
-// LoadNullInt loads `null.Int` from graphql arg
-func LoadNullInt(i interface{}) (null.Int, error) {
-	b, ok := i.(int)
+// loadBoundedInt loads an int from graphql arg, and checks that it's between min and max, which are
+// the limits of the Go type named by typeName.
+func loadBoundedInt(i interface{}, min, max int64, typeName string) (int64, error) {
+	n, ok := i.(int)
 	if !ok {
-		return null.Int{}, fmt.Errorf("%v is not an int", i)
+		return 0, fmt.Errorf("%v is not an int", i)
+	}
+	if int64(n) < min || int64(n) > max {
+		return 0, fmt.Errorf("%d is out of range for %s", n, typeName)
 	}
-	return null.IntFrom(int64(b)), nil
+	return int64(n), nil
 }
 
-// LoadNullString will attempt to load a `null.String` from graphql arg
-func LoadNullString(i interface{}) (null.String, error) {
-	str, ok := i.(string)
+// LoadInt8 loads `int8` from graphql arg
+func LoadInt8(i interface{}) (int8, error) {
+	n, err := loadBoundedInt(i, math.MinInt8, math.MaxInt8, "int8")
+	return int8(n), err
+}
+
+// LoadInt16 loads `int16` from graphql arg
+func LoadInt16(i interface{}) (int16, error) {
+	n, err := loadBoundedInt(i, math.MinInt16, math.MaxInt16, "int16")
+	return int16(n), err
+}
+
+// LoadInt32 loads `int32` from graphql arg
+func LoadInt32(i interface{}) (int32, error) {
+	n, err := loadBoundedInt(i, math.MinInt32, math.MaxInt32, "int32")
+	return int32(n), err
+}
+
+// LoadUInt8 loads `uint8` from graphql arg
+func LoadUInt8(i interface{}) (uint8, error) {
+	n, err := loadBoundedInt(i, 0, math.MaxUint8, "uint8")
+	return uint8(n), err
+}
+
+// LoadUInt16 loads `uint16` from graphql arg
+func LoadUInt16(i interface{}) (uint16, error) {
+	n, err := loadBoundedInt(i, 0, math.MaxUint16, "uint16")
+	return uint16(n), err
+}
+
+// loadLong loads the decimal string for an integer from a Long graphql arg.
+func loadLong(i interface{}) (string, error) {
+	s, ok := longParseValue(i).(string)
 	if !ok {
-		return null.String{}, fmt.Errorf("%v is not a string", i)
+		return "", fmt.Errorf("%v is not an integer", i)
 	}
-	return null.StringFrom(str), nil
+	return s, nil
+}
+
+// LoadInt64 loads `int64` from a Long graphql arg
+func LoadInt64(i interface{}) (int64, error) {
+	s, err := loadLong(i)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is out of range for int64", s)
+	}
+	return n, nil
+}
+
+// LoadUInt32 loads `uint32` from a Long graphql arg, since GraphQL's Int can't hold all of them.
+func LoadUInt32(i interface{}) (uint32, error) {
+	s, err := loadLong(i)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s is out of range for uint32", s)
+	}
+	return uint32(n), nil
+}
+
+// LoadUInt64 loads `uint64` from a Long graphql arg
+func LoadUInt64(i interface{}) (uint64, error) {
+	s, err := loadLong(i)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is out of range for uint64", s)
+	}
+	return n, nil
 }
 
 // LoadFloat loads `float` from graphql arg
@@ -88,6 +180,18 @@ func LoadFloat(i interface{}) (float64, error) {
 	return b, nil
 }
 
+// LoadFloat32 loads `float32` from graphql arg
+func LoadFloat32(i interface{}) (float32, error) {
+	f, err := LoadFloat(i)
+	if err != nil {
+		return 0, err
+	}
+	if math.Abs(f) > math.MaxFloat32 {
+		return 0, fmt.Errorf("%v is out of range for float32", f)
+	}
+	return float32(f), nil
+}
+
 // LoadTime loads `time.Time` from graphql arg
 func LoadTime(i interface{}) (time.Time, error) {
 	switch s := i.(type) {
@@ -100,6 +204,144 @@ func LoadTime(i interface{}) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("%v is not a ISO8601 timestamp", i)
 }
 
+// LoadTimeWithOptions loads `time.Time` from graphql arg.  The "layout" tag option gives a
+// time.Parse layout to use for string values instead of ISO8601, and the "tz" tag option names the
+// location that zoneless strings are assumed to be in and that all times are converted to.
+func LoadTimeWithOptions(i interface{}, opts TagOptions) (time.Time, error) {
+	loc := time.UTC
+	if tz := opts.Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return time.Time{}, fmt.Errorf("%s is not a known time zone", tz)
+		}
+	}
+
+	var t time.Time
+	switch s := i.(type) {
+	case string:
+		var err error
+		if layout := opts.Get("layout"); layout != "" {
+			t, err = time.ParseInLocation(layout, s, loc)
+		} else {
+			t, err = datetime.Parse(s, loc)
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	case time.Time:
+		t = s
+	default:
+		return time.Time{}, fmt.Errorf("%v is not a ISO8601 timestamp", i)
+	}
+
+	if opts.Has("tz") {
+		t = t.In(loc)
+	}
+	return t, nil
+}
+
+// loadNullable loads a nullable wrapper type W around the T that load returns.  A null arg gives
+// the zero W, which is not Valid.
+func loadNullable[T, W any](i interface{}, load func(interface{}) (T, error), wrap func(T) W) (W, error) {
+	var w W
+	if i == nil {
+		return w, nil
+	}
+	v, err := load(i)
+	if err != nil {
+		return w, err
+	}
+	return wrap(v), nil
+}
+
+// LoadNullInt loads `null.Int` from a Long graphql arg, since it holds an int64.
+func LoadNullInt(i interface{}) (null.Int, error) {
+	return loadNullable(i, LoadInt64, null.IntFrom)
+}
+
+// LoadNullString will attempt to load a `null.String` from graphql arg
+func LoadNullString(i interface{}) (null.String, error) {
+	return loadNullable(i, LoadString, null.StringFrom)
+}
+
+// LoadNullBool loads `null.Bool` from graphql arg
+func LoadNullBool(i interface{}) (null.Bool, error) {
+	return loadNullable(i, LoadBool, null.BoolFrom)
+}
+
+// LoadNullFloat loads `null.Float` from graphql arg
+func LoadNullFloat(i interface{}) (null.Float, error) {
+	return loadNullable(i, LoadFloat, null.FloatFrom)
+}
+
+// LoadNullTime loads `null.Time` from graphql arg
+func LoadNullTime(i interface{}) (null.Time, error) {
+	return loadNullable(i, LoadTime, null.TimeFrom)
+}
+
+// LoadSQLNullString loads `sql.NullString` from graphql arg
+func LoadSQLNullString(i interface{}) (sql.NullString, error) {
+	return loadNullable(i, LoadString, func(v string) sql.NullString {
+		return sql.NullString{String: v, Valid: true}
+	})
+}
+
+// LoadSQLNullInt64 loads `sql.NullInt64` from a Long graphql arg, since it holds an int64.
+func LoadSQLNullInt64(i interface{}) (sql.NullInt64, error) {
+	return loadNullable(i, LoadInt64, func(v int64) sql.NullInt64 {
+		return sql.NullInt64{Int64: v, Valid: true}
+	})
+}
+
+// LoadSQLNullInt32 loads `sql.NullInt32` from graphql arg
+func LoadSQLNullInt32(i interface{}) (sql.NullInt32, error) {
+	return loadNullable(i, LoadInt32, func(v int32) sql.NullInt32 {
+		return sql.NullInt32{Int32: v, Valid: true}
+	})
+}
+
+// LoadSQLNullInt16 loads `sql.NullInt16` from graphql arg
+func LoadSQLNullInt16(i interface{}) (sql.NullInt16, error) {
+	return loadNullable(i, LoadInt16, func(v int16) sql.NullInt16 {
+		return sql.NullInt16{Int16: v, Valid: true}
+	})
+}
+
+// LoadSQLNullByte loads `sql.NullByte` from graphql arg
+func LoadSQLNullByte(i interface{}) (sql.NullByte, error) {
+	return loadNullable(i, LoadUInt8, func(v uint8) sql.NullByte {
+		return sql.NullByte{Byte: v, Valid: true}
+	})
+}
+
+// LoadSQLNullFloat64 loads `sql.NullFloat64` from graphql arg
+func LoadSQLNullFloat64(i interface{}) (sql.NullFloat64, error) {
+	return loadNullable(i, LoadFloat, func(v float64) sql.NullFloat64 {
+		return sql.NullFloat64{Float64: v, Valid: true}
+	})
+}
+
+// LoadSQLNullBool loads `sql.NullBool` from graphql arg
+func LoadSQLNullBool(i interface{}) (sql.NullBool, error) {
+	return loadNullable(i, LoadBool, func(v bool) sql.NullBool {
+		return sql.NullBool{Bool: v, Valid: true}
+	})
+}
+
+// LoadSQLNullTime loads `sql.NullTime` from graphql arg
+func LoadSQLNullTime(i interface{}) (sql.NullTime, error) {
+	return loadNullable(i, LoadTime, func(v time.Time) sql.NullTime {
+		return sql.NullTime{Time: v, Valid: true}
+	})
+}
+
+// LoadPQNullTime loads `pq.NullTime` from graphql arg
+func LoadPQNullTime(i interface{}) (pq.NullTime, error) {
+	return loadNullable(i, LoadTime, func(v time.Time) pq.NullTime {
+		return pq.NullTime{Time: v, Valid: true}
+	})
+}
+
 // LoadRawJSON loads `pqjson.RawMessage` from graphql arg
 func LoadRawJSON(i interface{}) (pqjson.RawMessage, error) {
 	switch value := i.(type) {
@@ -0,0 +1,244 @@
+package sugar
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+)
+
+// PageArgs are the arguments of a Relay-style connection field.  Embed it in an arg struct to add
+// them to the field, alongside any others.  Its fields are read with the default arg and desc tags,
+// even by ArgLoaders built with WithTag or WithDescriptionTag.
+type PageArgs struct {
+	First  *int   `arg:"first,min:0" desc:"Returns the first n items after the after cursor."`
+	After  string `arg:"after" desc:"Returns items after this cursor."`
+	Last   *int   `arg:"last,min:0" desc:"Returns the last n items before the before cursor."`
+	Before string `arg:"before" desc:"Returns items before this cursor."`
+}
+
+// Limit returns how many items were asked for: first, or last when paging backward, or def if
+// neither was given.
+func (p PageArgs) Limit(def int) int {
+	switch {
+	case p.Backward() && p.Last != nil:
+		return *p.Last
+	case p.First != nil:
+		return *p.First
+	}
+	return def
+}
+
+// Backward reports whether the client is paging backward, with last or before, instead of forward.
+func (p PageArgs) Backward() bool {
+	return p.First == nil && (p.Last != nil || p.Before != "")
+}
+
+// Cursor returns the cursor to page from: before when paging backward, and after otherwise.
+func (p PageArgs) Cursor() string {
+	if p.Backward() {
+		return p.Before
+	}
+	return p.After
+}
+
+// Connection is the Go value for a connection type built by TypeBuilder.Connection.
+type Connection[T any] struct {
+	Edges    []Edge[T] `json:"edges"`
+	PageInfo PageInfo  `json:"pageInfo"`
+}
+
+// Edge is one item in a Connection, with the cursor that points at it.
+type Edge[T any] struct {
+	Cursor string `json:"cursor"`
+	Node   T      `json:"node"`
+}
+
+// PageInfo describes the page of a Connection.
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// Connection builds a Relay-style connection type named name + "Connection", whose edges hold
+// nodes of the GraphQL type that OutputType builds for nodeVal, with name as its name.  Resolve
+// the field to a Connection[T].  The PageInfo type is shared by all of a TypeBuilder's connections.
+// Connection panics if one of the names is already used for another type built by a helper.
+func (tb *TypeBuilder) Connection(name string, nodeVal interface{}) *graphql.Object {
+	nodeType := tb.OutputType(name, "", nodeVal)
+	pageInfo, err := tb.namedType("PageInfo", namedKey{kind: "the page info"}, func() graphql.Output {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name:        "PageInfo",
+			Description: "Information about a page of a connection.",
+			Fields: graphql.Fields{
+				"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"startCursor":     &graphql.Field{Type: graphql.String},
+				"endCursor":       &graphql.Field{Type: graphql.String},
+			},
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	conn, err := tb.namedType(name+"Connection", namedKey{"a connection of", nodeType}, func() graphql.Output {
+		edge := graphql.NewObject(graphql.ObjectConfig{
+			Name:        name + "Edge",
+			Description: fmt.Sprintf("An edge in a connection of %s.", nodeType.Name()),
+			Fields: graphql.Fields{
+				"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"node":   &graphql.Field{Type: nodeType},
+			},
+		})
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name:        name + "Connection",
+			Description: fmt.Sprintf("A connection of %s.", nodeType.Name()),
+			Fields: graphql.Fields{
+				"edges":    &graphql.Field{Type: graphql.NewList(edge)},
+				"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfo)},
+			},
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	return conn.(*graphql.Object)
+}
+
+// EncodeCursor turns a key, such as the sort key of a row, into an opaque cursor.
+func EncodeCursor(key interface{}) (string, error) {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor reads the key from a cursor made by EncodeCursor into key, which should be a
+// pointer.
+func DecodeCursor(cursor string, key interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, key)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid cursor", cursor)
+	}
+	return nil
+}
+
+// an offsetCursor is the key of an item in a slice given to ConnectionFromSlice.
+type offsetCursor struct {
+	Offset int `json:"o"`
+}
+
+// ConnectionFromSlice returns the page of items that args ask for, with cursors that record each
+// item's offset in items.  It's for lists that are loaded into memory in full.
+func ConnectionFromSlice[T any](items []T, args PageArgs) (Connection[T], error) {
+	start, end := 0, len(items)
+	for _, c := range []struct {
+		cursor string
+		after  bool
+	}{{args.After, true}, {args.Before, false}} {
+		if c.cursor == "" {
+			continue
+		}
+		var key offsetCursor
+		if err := DecodeCursor(c.cursor, &key); err != nil {
+			return Connection[T]{}, err
+		}
+		if c.after && key.Offset+1 > start {
+			start = key.Offset + 1
+		}
+		if !c.after && key.Offset < end {
+			end = key.Offset
+		}
+	}
+	if start > end {
+		start = end
+	}
+
+	// like graphql-relay-js, only report pages in the direction being paged.
+	conn := Connection[T]{}
+	if args.First != nil {
+		if *args.First < 0 {
+			return Connection[T]{}, errors.New("first cannot be negative")
+		}
+		if start+*args.First < end {
+			end = start + *args.First
+			conn.PageInfo.HasNextPage = true
+		}
+	}
+	if args.Last != nil {
+		if *args.Last < 0 {
+			return Connection[T]{}, errors.New("last cannot be negative")
+		}
+		if end-*args.Last > start {
+			start = end - *args.Last
+			conn.PageInfo.HasPreviousPage = true
+		}
+	}
+
+	conn.Edges = make([]Edge[T], 0, end-start)
+	for i := start; i < end; i++ {
+		cursor, err := EncodeCursor(offsetCursor{Offset: i})
+		if err != nil {
+			return Connection[T]{}, err
+		}
+		conn.Edges = append(conn.Edges, Edge[T]{Cursor: cursor, Node: items[i]})
+	}
+	conn.setCursors()
+	return conn, nil
+}
+
+// ConnectionFromKeyset wraps one page of a keyset query in a Connection.  rows should be read from
+// args.Cursor(), in the direction given by args.Backward() (so in reverse order when paging
+// backward), with a limit of one more than args.Limit(def).  The extra row, if there is one, only
+// tells us that there's another page.  cursor returns the cursor for a row, usually by passing its
+// sort key to EncodeCursor.
+func ConnectionFromKeyset[T any](rows []T, args PageArgs, def int, cursor func(T) (string, error)) (Connection[T], error) {
+	limit := args.Limit(def)
+	if limit < 0 {
+		return Connection[T]{}, errors.New("the page size cannot be negative")
+	}
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	conn := Connection[T]{Edges: make([]Edge[T], len(rows))}
+	for i, row := range rows {
+		c, err := cursor(row)
+		if err != nil {
+			return Connection[T]{}, err
+		}
+		if args.Backward() {
+			// put rows read backward back in order.
+			conn.Edges[len(rows)-1-i] = Edge[T]{Cursor: c, Node: row}
+		} else {
+			conn.Edges[i] = Edge[T]{Cursor: c, Node: row}
+		}
+	}
+	if args.Backward() {
+		conn.PageInfo.HasPreviousPage = more
+		conn.PageInfo.HasNextPage = args.Before != ""
+	} else {
+		conn.PageInfo.HasNextPage = more
+		conn.PageInfo.HasPreviousPage = args.After != ""
+	}
+	conn.setCursors()
+	return conn, nil
+}
+
+// setCursors sets the start and end cursors from the edges.
+func (c *Connection[T]) setCursors() {
+	if len(c.Edges) == 0 {
+		return
+	}
+	c.PageInfo.StartCursor = &c.Edges[0].Cursor
+	c.PageInfo.EndCursor = &c.Edges[len(c.Edges)-1].Cursor
+}
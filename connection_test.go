@@ -0,0 +1,164 @@
+package sugar
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPetsArgs struct {
+	PageArgs
+	Species string `arg:"species"`
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func nodes[T any](conn Connection[T]) []T {
+	out := []T{}
+	for _, e := range conn.Edges {
+		out = append(out, e.Node)
+	}
+	return out
+}
+
+func TestPageArgs(t *testing.T) {
+	conf, err := SafeArgsConfig(testPetsArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 5)
+	assert.Equal(t, "Int", conf["first"].Type.String())
+
+	args := testPetsArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"first": 10,
+		"after": "abc",
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testPetsArgs{PageArgs: PageArgs{First: intPtr(10), After: "abc"}}, args)
+	assert.Equal(t, 10, args.Limit(20))
+	assert.False(t, args.Backward())
+	assert.Equal(t, "abc", args.Cursor())
+
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"last": -1}}, &args)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "last must be at least 0")
+	}
+}
+
+func TestConnectionFromSlice(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+
+	conn, err := ConnectionFromSlice(items, PageArgs{First: intPtr(2)})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, nodes(conn))
+	assert.True(t, conn.PageInfo.HasNextPage)
+	assert.False(t, conn.PageInfo.HasPreviousPage)
+	assert.Equal(t, conn.Edges[1].Cursor, *conn.PageInfo.EndCursor)
+
+	conn, err = ConnectionFromSlice(items, PageArgs{First: intPtr(2), After: *conn.PageInfo.EndCursor})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"c", "d"}, nodes(conn))
+
+	conn, err = ConnectionFromSlice(items, PageArgs{Last: intPtr(2), Before: conn.Edges[1].Cursor})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "c"}, nodes(conn))
+	assert.True(t, conn.PageInfo.HasPreviousPage)
+
+	conn, err = ConnectionFromSlice(items, PageArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conn.Edges, 5)
+
+	_, err = ConnectionFromSlice(items, PageArgs{After: "nope!"})
+	assert.NotNil(t, err)
+}
+
+func TestConnectionFromKeyset(t *testing.T) {
+	cursor := func(id int) (string, error) { return EncodeCursor(id) }
+
+	// forward, with one row more than the limit.
+	conn, err := ConnectionFromKeyset([]int{4, 5, 6}, PageArgs{First: intPtr(2), After: "x"}, 10, cursor)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4, 5}, nodes(conn))
+	assert.True(t, conn.PageInfo.HasNextPage)
+	assert.True(t, conn.PageInfo.HasPreviousPage)
+	var key int
+	assert.Nil(t, DecodeCursor(*conn.PageInfo.EndCursor, &key))
+	assert.Equal(t, 5, key)
+
+	// backward rows arrive in reverse.
+	conn, err = ConnectionFromKeyset([]int{3, 2}, PageArgs{Last: intPtr(2), Before: "x"}, 10, cursor)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 3}, nodes(conn))
+	assert.False(t, conn.PageInfo.HasPreviousPage)
+	assert.True(t, conn.PageInfo.HasNextPage)
+
+	_, err = ConnectionFromKeyset([]int{1}, PageArgs{}, -1, cursor)
+	assert.NotNil(t, err)
+}
+
+type testGqlPetsArgs struct {
+	PageArgs
+	Species string `gql:"species" about:"Only pets of this species."`
+}
+
+func TestPageArgsWithTag(t *testing.T) {
+	loader, err := New(WithTag("gql"), WithDescriptionTag("about"))
+	assert.Nil(t, err)
+	conf, err := loader.SafeArgsConfig(testGqlPetsArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 5)
+	assert.Equal(t, "Returns items after this cursor.", conf["after"].Description)
+	assert.Equal(t, "Only pets of this species.", conf["species"].Description)
+
+	args := testGqlPetsArgs{}
+	assert.Nil(t, loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"first": 2, "species": "cat"}}, &args))
+	assert.Equal(t, testGqlPetsArgs{PageArgs: PageArgs{First: intPtr(2)}, Species: "cat"}, args)
+}
+
+type testConnPet struct {
+	Name string `json:"name"`
+}
+
+func TestConnectionType(t *testing.T) {
+	tb := NewTypeBuilder()
+	conn := tb.Connection("TestConnPet", testConnPet{})
+	assert.Equal(t, "TestConnPetConnection", conn.Name())
+	assert.Equal(t, conn, tb.Connection("TestConnPet", testConnPet{}))
+	assert.Equal(t, "PageInfo!", conn.Fields()["pageInfo"].Type.String())
+	tb.FilterInput("OtherConnection", testFilterPet{})
+	assert.Panics(t, func() { tb.Connection("Other", testConnPet{}) })
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"pets": &graphql.Field{
+					Type: conn,
+					Args: ArgsConfig(testPetsArgs{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testPetsArgs{}
+						if err := LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						pets := []testConnPet{{Name: "rex"}, {Name: "tom"}}
+						return ConnectionFromSlice(pets, args.PageArgs)
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ pets(first: 1) { edges { node { name } } pageInfo { hasNextPage } } }`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"pets": map[string]interface{}{
+			"edges":    []interface{}{map[string]interface{}{"node": map[string]interface{}{"name": "rex"}}},
+			"pageInfo": map[string]interface{}{"hasNextPage": true},
+		},
+	}, result.Data)
+}
@@ -32,12 +32,55 @@ func SafeArgsConfig(i interface{}) (graphql.FieldConfigArgument, error) {
 
 // RegisterArgParser takes a func (string) (<anytype>, error) and registers it on the ArgLoader as
 // the parser for <anytype>.  It uses the default arg loader.
-func RegisterArgParser(f interface{}, gqlType graphql.Output) error {
+func RegisterArgParser(f interface{}, gqlType graphql.Input) error {
 	return defaultLoader.RegisterArgParser(f, gqlType)
 }
 
+// RegisterInputType registers a loader for the struct type of val that loads it from gqlType by its
+// json tags.  It uses the default arg loader.
+func RegisterInputType(val interface{}, gqlType graphql.Input) error {
+	return defaultLoader.RegisterInputType(val, gqlType)
+}
+
+// RegisterValidator makes f available as a named option in the arg tag.  It uses the default arg
+// loader.
+func RegisterValidator(name string, f ValidatorFunc) error {
+	return defaultLoader.RegisterValidator(name, f)
+}
+
+// Clone returns a new ArgLoader with the default arg loader's loaders, validators, and settings, to
+// be customized without affecting the default.
+func Clone() *ArgLoader {
+	return defaultLoader.Clone()
+}
+
 // LoadArgs loads arguments from the ResolveParam's map into the provided struct.  It uses the
 // default arg loader.
 func LoadArgs(p graphql.ResolveParams, c interface{}) error {
 	return defaultLoader.LoadArgs(p, c)
 }
+
+// LoadFilter loads a Filter for the struct type of val from an argument of the type built by
+// FilterInput.  It uses the default arg loader.
+func LoadFilter(val interface{}, i interface{}) (Filter, error) {
+	return defaultLoader.LoadFilter(val, i)
+}
+
+// LoadOrderBy loads OrderTerms for the struct type of val from an argument of the type built by
+// OrderBy.  It uses the default arg loader.
+func LoadOrderBy(val interface{}, i interface{}) ([]OrderTerm, error) {
+	return defaultLoader.LoadOrderBy(val, i)
+}
+
+// AllowNullRequired controls whether SafeArgsConfig describes required arguments with nullable
+// GraphQL types.  It configures the default arg loader.
+func AllowNullRequired(allow bool) {
+	defaultLoader.AllowNullRequired(allow)
+}
+
+// Freeze stops further registration on both the default arg loader and the default type builder.
+// Call it once the schema is built.
+func Freeze() {
+	defaultLoader.Freeze()
+	defaultTypeBuilder.Freeze()
+}
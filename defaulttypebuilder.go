@@ -14,12 +14,41 @@ func RegisterKnownType(val interface{}, gqlType graphql.Output) {
 	defaultTypeBuilder.RegisterKnownType(val, gqlType)
 }
 
+// SafeRegisterKnownType is like RegisterKnownType, but returns an error instead of panicking.
+func SafeRegisterKnownType(val interface{}, gqlType graphql.Output) error {
+	return defaultTypeBuilder.SafeRegisterKnownType(val, gqlType)
+}
+
 // OutputType builds a GraphQL type for you from the given name, desc, and val.  val may be a
 // struct instance, slice, array, pointer, or an alias to a builtin type like int or string.
 func OutputType(name, desc string, val interface{}) graphql.Output {
 	return defaultTypeBuilder.OutputType(name, desc, val)
 }
 
+// InputType builds a GraphQL input type for you from the given name, desc, and val, reading struct
+// fields from their json tags like OutputType does.
+func InputType(name, desc string, val interface{}) graphql.Input {
+	return defaultTypeBuilder.InputType(name, desc, val)
+}
+
+// ConnectionType builds a Relay-style connection type whose edges hold nodes of the type that
+// OutputType builds for nodeVal.  See TypeBuilder.Connection.
+func ConnectionType(name string, nodeVal interface{}) *graphql.Object {
+	return defaultTypeBuilder.Connection(name, nodeVal)
+}
+
+// FilterInput builds a where-style input object for filtering the struct type of val.  See
+// TypeBuilder.FilterInput.
+func FilterInput(name string, val interface{}) *graphql.InputObject {
+	return defaultTypeBuilder.FilterInput(name, val)
+}
+
+// OrderBy builds a list input type for sorting the struct type of val by its sortable fields.  See
+// TypeBuilder.OrderBy.
+func OrderBy(name string, val interface{}) graphql.Input {
+	return defaultTypeBuilder.OrderBy(name, val)
+}
+
 // Union is just like OutputType, but takes in multiple vals and builds a GraphQL union type out of
 // them.
 func Union(name, desc string, vals ...interface{}) *graphql.Union {
@@ -0,0 +1,118 @@
+package sugar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// enums remembers the GraphQL enum built for each Go type, so that arguments and output fields of
+// that type share a single GraphQL type.
+var (
+	enumsMu sync.Mutex
+	enums   = map[reflect.Type]builtEnum{}
+)
+
+// a builtEnum is an enum, and the values it was built from.
+type builtEnum struct {
+	enum   *graphql.Enum
+	values map[string]interface{}
+}
+
+// Enum builds a GraphQL enum for the Go type of val.  values must be a map from GraphQL value names
+// to values of that same Go type, like map[string]Status{"ACTIVE": StatusActive}.  The enum is
+// named after the Go type, and built only once; later calls for the same type with the same values
+// return it unchanged, and calls with different values return an error.
+func Enum(val interface{}, values interface{}) (*graphql.Enum, error) {
+	t := getType(val)
+	valuesMap := reflect.ValueOf(values)
+	if valuesMap.Kind() != reflect.Map || valuesMap.Type().Key().Kind() != reflect.String ||
+		valuesMap.Type().Elem() != t {
+		return nil, fmt.Errorf("enum values for %v should be a map[string]%v, not %T", t, t, values)
+	}
+	if valuesMap.Len() == 0 {
+		return nil, fmt.Errorf("no enum values given for %v", t)
+	}
+	if t.Name() == "" {
+		return nil, fmt.Errorf("cannot build an enum for unnamed type %v", t)
+	}
+	plain := map[string]interface{}{}
+	iter := valuesMap.MapRange()
+	for iter.Next() {
+		plain[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	if built, ok := enums[t]; ok {
+		if !reflect.DeepEqual(built.values, plain) {
+			return nil, fmt.Errorf("the enum for %v was already built with different values", t)
+		}
+		return built.enum, nil
+	}
+
+	config := graphql.EnumValueConfigMap{}
+	for name, v := range plain {
+		config[name] = &graphql.EnumValueConfig{Value: v}
+	}
+	enum := graphql.NewEnum(graphql.EnumConfig{
+		Name:   strings.ToUpper(t.Name()[:1]) + t.Name()[1:],
+		Values: config,
+	})
+	if err := enum.Error(); err != nil {
+		return nil, err
+	}
+	enums[t] = builtEnum{enum: enum, values: plain}
+	return enum, nil
+}
+
+// RegisterEnum builds a GraphQL enum for the Go type of val (see Enum), and registers a loader for
+// it that accepts only the given values.
+func (e *ArgLoader) RegisterEnum(val interface{}, values interface{}) error {
+	// check before building, so that a frozen ArgLoader doesn't add to the shared enums.
+	if e.isFrozen() {
+		return ErrFrozen
+	}
+	enum, err := Enum(val, values)
+	if err != nil {
+		return err
+	}
+
+	t := getType(val)
+	loader := func(i interface{}, config TagOptions) (reflect.Value, error) {
+		// graphql-go hands us the Go value for the enum, but accept value names too.
+		if name, ok := i.(string); ok {
+			i = enum.ParseValue(name)
+		}
+		if i == nil || reflect.TypeOf(i) != t || enum.Serialize(i) == nil {
+			return reflect.Value{}, fmt.Errorf("%v is not a valid %s", i, enum.Name())
+		}
+		return reflect.ValueOf(i), nil
+	}
+	return e.register(t, loader, enum, enum.Name())
+}
+
+// RegisterEnum builds a GraphQL enum for the Go type of val (see Enum), and registers it as the
+// output type for that Go type.
+func (tb *TypeBuilder) RegisterEnum(val interface{}, values interface{}) error {
+	if tb.isFrozen() {
+		return ErrFrozen
+	}
+	enum, err := Enum(val, values)
+	if err != nil {
+		return err
+	}
+	return tb.SafeRegisterKnownType(val, enum)
+}
+
+// RegisterEnum builds a GraphQL enum for the Go type of val (see Enum), and registers it on both
+// the default arg loader and the default type builder.
+func RegisterEnum(val interface{}, values interface{}) error {
+	if err := defaultLoader.RegisterEnum(val, values); err != nil {
+		return err
+	}
+	return defaultTypeBuilder.RegisterEnum(val, values)
+}
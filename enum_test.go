@@ -0,0 +1,73 @@
+package sugar
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus string
+
+const (
+	testStatusActive   testStatus = "active"
+	testStatusArchived testStatus = "archived"
+)
+
+type testStatusArgs struct {
+	Status   testStatus   `arg:"status"`
+	Statuses []testStatus `arg:"statuses"`
+}
+
+func TestEnum(t *testing.T) {
+	values := map[string]testStatus{"ACTIVE": testStatusActive, "ARCHIVED": testStatusArchived}
+	loader, err := New()
+	assert.Nil(t, err)
+	assert.Nil(t, loader.RegisterEnum(testStatus(""), values))
+	tb := NewTypeBuilder()
+	assert.Nil(t, tb.RegisterEnum(testStatus(""), values))
+
+	conf, err := loader.SafeArgsConfig(testStatusArgs{})
+	assert.Nil(t, err)
+	enum := conf["status"].Type
+	assert.Equal(t, "TestStatus", enum.Name())
+	assert.Equal(t, "[TestStatus]", conf["statuses"].Type.String())
+	assert.Equal(t, enum, tb.OutputType("Status", "", testStatusActive))
+
+	args := testStatusArgs{}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"status":   testStatusArchived,
+		"statuses": []interface{}{testStatusActive},
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testStatusArgs{Status: testStatusArchived, Statuses: []testStatus{testStatusActive}}, args)
+
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"status": testStatus("deleted"),
+	}}, &args)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "status is not valid")
+	}
+}
+
+type testShade string
+
+func TestEnumDifferentValues(t *testing.T) {
+	// a frozen loader doesn't build the enum, so it can't claim the type.
+	frozen, err := New()
+	assert.Nil(t, err)
+	frozen.Freeze()
+	assert.Equal(t, ErrFrozen, frozen.RegisterEnum(testShade(""), map[string]testShade{"PINK": "pink"}))
+
+	values := map[string]testShade{"RED": "red", "BLUE": "blue"}
+	enum, err := Enum(testShade(""), values)
+	assert.Nil(t, err)
+	again, err := Enum(testShade(""), map[string]testShade{"BLUE": "blue", "RED": "red"})
+	assert.Nil(t, err)
+	assert.Equal(t, enum, again)
+
+	_, err = Enum(testShade(""), map[string]testShade{"RED": "red"})
+	assert.NotNil(t, err)
+	tb := NewTypeBuilder()
+	assert.NotNil(t, tb.RegisterEnum(testShade(""), map[string]testShade{"GREEN": "green"}))
+}
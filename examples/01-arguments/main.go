@@ -103,22 +103,6 @@ type saveUserArgs struct {
 	FavoriteMovies   []string `arg:"favoriteMovies" desc:"A JSON-formatted list of this user's favorite movies."`
 }
 
-func init() {
-	parseStringArray := func(arg interface{}) ([]string, error) {
-		if ms, ok := arg.([]interface{}); ok {
-			out := []string{}
-			for _, m := range ms {
-				out = append(out, m.(string))
-			}
-			return out, nil
-		}
-		return nil, errors.New("invalid movie list")
-	}
-	if err := sugar.RegisterArgParser(parseStringArray, graphql.NewList(graphql.String)); err != nil {
-		panic(err)
-	}
-}
-
 func resolveSaveUser(p graphql.ResolveParams) (interface{}, error) {
 	args := saveUserArgs{}
 	if err := sugar.LoadArgs(p, &args); err != nil {
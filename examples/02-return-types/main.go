@@ -75,22 +75,6 @@ func resolveUser(p graphql.ResolveParams) (interface{}, error) {
 	}
 }
 
-func init() {
-	parseStringArray := func(arg interface{}) ([]string, error) {
-		if ms, ok := arg.([]interface{}); ok {
-			out := []string{}
-			for _, m := range ms {
-				out = append(out, m.(string))
-			}
-			return out, nil
-		}
-		return nil, errors.New("invalid string array")
-	}
-	if err := sugar.RegisterArgParser(parseStringArray, graphql.NewList(graphql.String)); err != nil {
-		panic(err)
-	}
-}
-
 func resolveSaveUser(p graphql.ResolveParams) (interface{}, error) {
 	u := user{}
 	if err := sugar.LoadArgs(p, &u); err != nil {
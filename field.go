@@ -0,0 +1,60 @@
+package sugar
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Field builds a complete graphql.Field from a resolver func.  The field's arguments come from the
+// tagged fields of A, its type is built from R, and its Resolve func loads the arguments, calls fn,
+// and returns the result.  Argument problems are returned as ArgErrors, and errors from fn are
+// returned unchanged.  It uses the default arg loader and type builder.
+//
+// The returned field can be adjusted before use, for example to set a Description.
+func Field[A any, R any](fn func(context.Context, A) (R, error)) *graphql.Field {
+	return NewField(defaultLoader, defaultTypeBuilder, fn)
+}
+
+// NewField is like Field, but uses the given ArgLoader and TypeBuilder.
+func NewField[A any, R any](e *ArgLoader, tb *TypeBuilder, fn func(context.Context, A) (R, error)) *graphql.Field {
+	outType := typeOf[R]()
+	return &graphql.Field{
+		Type: tb.OutputType(outputTypeName(outType), "", outType),
+		Args: ArgsWith[A](e),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			args, err := LoadWith[A](e, p)
+			if err != nil {
+				return nil, err
+			}
+			ctx := p.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			out, err := fn(ctx, args)
+			if err != nil {
+				return nil, err
+			}
+			// don't hand graphql-go a typed nil.
+			if v := reflect.ValueOf(out); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+				return nil, nil
+			}
+			return out, nil
+		},
+	}
+}
+
+// outputTypeName names the GraphQL type for t after the Go type it holds, so that a func returning
+// *User or []User gets a User type.
+func outputTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	name := t.Name()
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
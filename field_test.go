@@ -0,0 +1,66 @@
+package sugar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFieldUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type testSaveUserArgs struct {
+	ID   string `arg:"id,required"`
+	Name string `arg:"name"`
+}
+
+func TestField(t *testing.T) {
+	field := NewField(defaultLoader, NewTypeBuilder(), func(ctx context.Context, args testSaveUserArgs) (*testFieldUser, error) {
+		if args.ID == "nobody" {
+			return nil, nil
+		}
+		if args.Name == "" {
+			return nil, errors.New("name is empty")
+		}
+		return &testFieldUser{ID: args.ID, Name: args.Name}, nil
+	})
+	assert.Equal(t, "TestFieldUser", field.Type.Name())
+	assert.Equal(t, "String!", field.Args["id"].Type.String())
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"saveUser": field},
+		}),
+	})
+	assert.Nil(t, err)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ saveUser(id: "bob", name: "Bob") { id name } }`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"saveUser": map[string]interface{}{"id": "bob", "name": "Bob"},
+	}, result.Data)
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ saveUser(id: "nobody") { id } }`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{"saveUser": nil}, result.Data)
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ saveUser(id: "bob") { id } }`,
+	})
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, "name is empty", result.Errors[0].Message)
+	}
+}
@@ -0,0 +1,617 @@
+package sugar
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// A FilterOp is an operator in a filter Condition.
+type FilterOp string
+
+// The operators offered by FilterInput.  Every field gets eq, ne and isNull.  Fields that aren't
+// booleans get in, strings get contains, and numbers and timestamps get lt and gt.
+const (
+	FilterEq       FilterOp = "eq"
+	FilterNe       FilterOp = "ne"
+	FilterIn       FilterOp = "in"
+	FilterLt       FilterOp = "lt"
+	FilterGt       FilterOp = "gt"
+	FilterContains FilterOp = "contains"
+	FilterIsNull   FilterOp = "isNull"
+)
+
+// A Filter is loaded from an input object built by FilterInput.  An item matches it when it
+// matches all of its Conditions and And filters, at least one of its Or filters if it has any, and
+// not its Not filter.
+type Filter struct {
+	Conditions []Condition
+	And        []Filter
+	Or         []Filter
+	Not        *Filter
+}
+
+// A Condition compares one field of an item to a value.
+type Condition struct {
+	// Field is the name of the field in the filter, from its json tag.
+	Field string
+	Op    FilterOp
+	// Value is of the field's Go type, without pointers.  For in it's a slice of that type, and for
+	// isNull it's a bool.
+	Value interface{}
+}
+
+// filterOps returns the operators offered for fields of the given GraphQL type.
+func filterOps(leaf graphql.Input) []FilterOp {
+	switch leaf {
+	case graphql.Boolean:
+		return []FilterOp{FilterEq, FilterNe, FilterIsNull}
+	case graphql.String:
+		return []FilterOp{FilterEq, FilterNe, FilterIn, FilterContains, FilterIsNull}
+	case graphql.Int, graphql.Float, Long, Timestamp:
+		return []FilterOp{FilterEq, FilterNe, FilterIn, FilterLt, FilterGt, FilterIsNull}
+	}
+	return []FilterOp{FilterEq, FilterNe, FilterIn, FilterIsNull}
+}
+
+// FilterInput builds a where-style input object named name from the json-tagged fields of val, a
+// struct.  Each scalar or enum field gets an input object of operators for its type, like
+// {eq: String, contains: String, ...}, and the and, or and not fields combine filters.  Fields of
+// other types are left out.  Load arguments of the new type with ArgLoader.LoadFilter, or use
+// Where[T] for arguments.  FilterInput panics if the type can't be built; see SafeFilterInput.
+func (tb *TypeBuilder) FilterInput(name string, val interface{}) *graphql.InputObject {
+	obj, err := tb.SafeFilterInput(name, val)
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// SafeFilterInput is like FilterInput, but returns an error instead of panicking.  It fails if val
+// has a field named and, or or not, or if one of the names is already used for another type built
+// by a helper.
+func (tb *TypeBuilder) SafeFilterInput(name string, val interface{}) (*graphql.InputObject, error) {
+	t := derefType(getType(val))
+	fields, err := filterFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	// build the operator inputs first, since namedType can't be called while building another
+	// type.
+	opInputs := graphql.InputObjectConfigFieldMap{}
+	for _, f := range fields {
+		leaf, ok := tb.leafInputType(f.field.Type)
+		if !ok {
+			continue
+		}
+		ops, err := tb.filterOpsInput(leaf)
+		if err != nil {
+			return nil, err
+		}
+		opInputs[f.name] = &graphql.InputObjectFieldConfig{
+			Type:        ops,
+			Description: f.field.Tag.Get("desc"),
+		}
+	}
+
+	obj, err := tb.namedType(name, namedKey{"a filter of", t}, func() graphql.Output {
+		fields := graphql.InputObjectConfigFieldMap{}
+		obj := graphql.NewInputObject(graphql.InputObjectConfig{
+			Name:   name,
+			Fields: fields,
+		})
+		for fieldName, field := range opInputs {
+			fields[fieldName] = field
+		}
+		fields["and"] = &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(obj)),
+			Description: "Matches items that match all of these filters.",
+		}
+		fields["or"] = &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(obj)),
+			Description: "Matches items that match any of these filters.",
+		}
+		fields["not"] = &graphql.InputObjectFieldConfig{
+			Type:        obj,
+			Description: "Matches items that don't match this filter.",
+		}
+		return obj
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*graphql.InputObject), nil
+}
+
+// filterFields returns the json-tagged fields of t by name, refusing the names of the fields that
+// combine filters.
+func filterFields(t reflect.Type) (map[string]jsonField, error) {
+	fields := jsonFieldMap(t)
+	for _, name := range []string{"and", "or", "not"} {
+		if _, ok := fields[name]; ok {
+			return nil, fmt.Errorf("cannot filter %v: the name of its %s field is used to combine filters", t, name)
+		}
+	}
+	return fields, nil
+}
+
+// filterOpsInput returns the input object of operators for fields of the leaf type, which is
+// shared by all of the TypeBuilder's filters.
+func (tb *TypeBuilder) filterOpsInput(leaf graphql.Input) (*graphql.InputObject, error) {
+	name := leaf.Name() + "Filter"
+	obj, err := tb.namedType(name, namedKey{"the filter operators for", leaf}, func() graphql.Output {
+		fields := graphql.InputObjectConfigFieldMap{}
+		for _, op := range filterOps(leaf) {
+			var t graphql.Input = leaf
+			switch op {
+			case FilterIn:
+				t = graphql.NewList(graphql.NewNonNull(leaf))
+			case FilterIsNull:
+				t = graphql.Boolean
+			}
+			fields[string(op)] = &graphql.InputObjectFieldConfig{Type: t}
+		}
+		return graphql.NewInputObject(graphql.InputObjectConfig{
+			Name:        name,
+			Description: fmt.Sprintf("Conditions on a %s field.", leaf.Name()),
+			Fields:      fields,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*graphql.InputObject), nil
+}
+
+// leafInputType returns the scalar or enum type for values of type t, if it has one.
+func (tb *TypeBuilder) leafInputType(t reflect.Type) (graphql.Input, bool) {
+	t = derefType(t)
+	if knownType, ok := (*tb.knownTypes.Load())[t]; ok {
+		switch knownType.(type) {
+		case *graphql.Scalar, *graphql.Enum:
+			return knownType, true
+		}
+		return nil, false
+	}
+	if scalar, ok := kindScalar(t.Kind()); ok {
+		return scalar, true
+	}
+	return nil, false
+}
+
+// LoadFilter loads a Filter from i, the value graphql-go provides for an argument of the type that
+// FilterInput builds for val.  Condition values are loaded with the ArgLoader's loaders for each
+// field's type.  Problems with i are returned as ArgErrors.
+func (e *ArgLoader) LoadFilter(val interface{}, i interface{}) (Filter, error) {
+	fields, err := filterFields(derefType(getType(val)))
+	if err != nil {
+		return Filter{}, err
+	}
+	valErrs := ArgErrors{}
+	f := e.loadFilter(fields, i, "", &valErrs)
+	if len(valErrs) > 0 {
+		return Filter{}, valErrs
+	}
+	return f, nil
+}
+
+// loadFilter loads a Filter from i.  Problems are appended to valErrs, with paths prefixed by
+// prefix.
+func (e *ArgLoader) loadFilter(fields map[string]jsonField, i interface{}, prefix string, valErrs *ArgErrors) Filter {
+	f := Filter{}
+	filterType := reflect.TypeOf(f)
+	args, ok := i.(map[string]interface{})
+	if !ok {
+		valErrs.add(newArgError(ErrCodeInvalidType, strings.TrimSuffix(prefix, "."), filterType, "",
+			fmt.Errorf("%v is not an input object", i)))
+		return f
+	}
+
+	// sort the keys so that conditions and errors come out in a stable order.
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := prefix + key
+		switch key {
+		case "and", "or":
+			list, ok := args[key].([]interface{})
+			if !ok {
+				valErrs.add(newArgError(ErrCodeInvalidType, path, filterType, "", fmt.Errorf("%v is not a list", args[key])))
+				continue
+			}
+			for j, item := range list {
+				sub := e.loadFilter(fields, item, fmt.Sprintf("%s[%d].", path, j), valErrs)
+				if key == "and" {
+					f.And = append(f.And, sub)
+				} else {
+					f.Or = append(f.Or, sub)
+				}
+			}
+		case "not":
+			sub := e.loadFilter(fields, args[key], path+".", valErrs)
+			f.Not = &sub
+		default:
+			field, ok := fields[key]
+			if !ok {
+				valErrs.add(newArgError(ErrCodeInvalidType, path, nil, "", fmt.Errorf("is not a field that can be filtered")))
+				continue
+			}
+			ops, ok := args[key].(map[string]interface{})
+			if !ok {
+				valErrs.add(newArgError(ErrCodeInvalidType, path, nil, "", fmt.Errorf("%v is not an input object", args[key])))
+				continue
+			}
+			opNames := make([]string, 0, len(ops))
+			for op := range ops {
+				opNames = append(opNames, op)
+			}
+			sort.Strings(opNames)
+			for _, op := range opNames {
+				if c, ok := e.loadCondition(field, FilterOp(op), ops[op], path+"."+op, valErrs); ok {
+					f.Conditions = append(f.Conditions, c)
+				}
+			}
+		}
+	}
+	return f
+}
+
+// loadCondition loads the value for one operator on a field.
+func (e *ArgLoader) loadCondition(field jsonField, op FilterOp, i interface{}, path string, valErrs *ArgErrors) (Condition, bool) {
+	t := derefType(field.field.Type)
+	load := func(i interface{}, path string) (reflect.Value, bool) {
+		v, err := e.filterLoader(t)(i, path, valErrs)
+		if err != nil {
+			valErrs.add(newArgError(ErrCodeInvalidType, path, t, e.expectedType(t), err))
+			return reflect.Value{}, false
+		}
+		return v, v.IsValid()
+	}
+
+	c := Condition{Field: field.name, Op: op}
+	switch op {
+	case FilterIsNull:
+		b, ok := i.(bool)
+		if !ok {
+			valErrs.add(newArgError(ErrCodeInvalidType, path, reflect.TypeOf(b), "Boolean", fmt.Errorf("%v is not a bool", i)))
+			return c, false
+		}
+		c.Value = b
+	case FilterIn:
+		list, ok := i.([]interface{})
+		if !ok {
+			valErrs.add(newArgError(ErrCodeInvalidType, path, reflect.SliceOf(t), "", fmt.Errorf("%v is not a list", i)))
+			return c, false
+		}
+		values := reflect.MakeSlice(reflect.SliceOf(t), 0, len(list))
+		for j, item := range list {
+			v, ok := load(item, fmt.Sprintf("%s[%d]", path, j))
+			if !ok {
+				return c, false
+			}
+			values = reflect.Append(values, v)
+		}
+		c.Value = values.Interface()
+	case FilterEq, FilterNe, FilterLt, FilterGt, FilterContains:
+		v, ok := load(i, path)
+		if !ok {
+			return c, false
+		}
+		c.Value = v.Interface()
+	default:
+		valErrs.add(newArgError(ErrCodeInvalidType, path, nil, "", fmt.Errorf("is not a filter operator")))
+		return c, false
+	}
+	return c, true
+}
+
+// Match reports whether item, a struct or a pointer to one, matches the filter.  Conditions other
+// than isNull never match a null field.
+func (f Filter) Match(item interface{}) bool {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	return f.match(v, jsonFieldMap(v.Type()))
+}
+
+func (f Filter) match(v reflect.Value, fields map[string]jsonField) bool {
+	for _, c := range f.Conditions {
+		if !c.match(v, fields) {
+			return false
+		}
+	}
+	for _, sub := range f.And {
+		if !sub.match(v, fields) {
+			return false
+		}
+	}
+	if len(f.Or) > 0 {
+		matched := false
+		for _, sub := range f.Or {
+			if sub.match(v, fields) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return f.Not == nil || !f.Not.match(v, fields)
+}
+
+func (c Condition) match(v reflect.Value, fields map[string]jsonField) bool {
+	field, ok := fields[c.Field]
+	if !ok {
+		return false
+	}
+	var value interface{}
+	isNull := true
+	if fv, err := v.FieldByIndexErr(field.index); err == nil {
+		value, isNull = plainValue(fv)
+	}
+
+	if c.Op == FilterIsNull {
+		want, _ := c.Value.(bool)
+		return isNull == want
+	}
+	if isNull {
+		return false
+	}
+	switch c.Op {
+	case FilterEq:
+		return equalValues(value, c.Value)
+	case FilterNe:
+		return !equalValues(value, c.Value)
+	case FilterIn:
+		list := reflect.ValueOf(c.Value)
+		if list.Kind() != reflect.Slice {
+			return false
+		}
+		for j := 0; j < list.Len(); j++ {
+			if equalValues(value, list.Index(j).Interface()) {
+				return true
+			}
+		}
+		return false
+	case FilterLt:
+		n, ok := compareValues(value, c.Value)
+		return ok && n < 0
+	case FilterGt:
+		n, ok := compareValues(value, c.Value)
+		return ok && n > 0
+	case FilterContains:
+		sub, _ := plainValue(reflect.ValueOf(c.Value))
+		s, ok := value.(string)
+		subString, subOK := sub.(string)
+		return ok && subOK && strings.Contains(s, subString)
+	}
+	return false
+}
+
+// FilterSlice returns the items that match the filter.
+func FilterSlice[T any](items []T, f Filter) []T {
+	out := []T{}
+	for _, item := range items {
+		if f.Match(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// A FilterBackend translates Filters into queries for some data store, like SQL WHERE clauses.  Q
+// is the type of a translated query.
+type FilterBackend[Q any] interface {
+	// Condition translates one condition.
+	Condition(c Condition) (Q, error)
+	// And combines queries that must all match.  It's given none for an empty filter, which should
+	// match everything.
+	And(qs []Q) Q
+	// Or combines queries of which at least one must match.
+	Or(qs []Q) Q
+	// Not negates a query.
+	Not(q Q) Q
+}
+
+// TranslateFilter translates f into a query with backend.
+func TranslateFilter[Q any](f Filter, backend FilterBackend[Q]) (Q, error) {
+	var zero Q
+	parts := []Q{}
+	for _, c := range f.Conditions {
+		q, err := backend.Condition(c)
+		if err != nil {
+			return zero, err
+		}
+		parts = append(parts, q)
+	}
+	for _, sub := range f.And {
+		q, err := TranslateFilter(sub, backend)
+		if err != nil {
+			return zero, err
+		}
+		parts = append(parts, q)
+	}
+	if len(f.Or) > 0 {
+		or := []Q{}
+		for _, sub := range f.Or {
+			q, err := TranslateFilter(sub, backend)
+			if err != nil {
+				return zero, err
+			}
+			or = append(or, q)
+		}
+		parts = append(parts, backend.Or(or))
+	}
+	if f.Not != nil {
+		q, err := TranslateFilter(*f.Not, backend)
+		if err != nil {
+			return zero, err
+		}
+		parts = append(parts, backend.Not(q))
+	}
+	return backend.And(parts), nil
+}
+
+// Where is an argument type for filtering Ts.  Its GraphQL type is the input object that
+// FilterInput builds for T, named after T with a "Filter" suffix, and it's loaded with
+// ArgLoader.LoadFilter.  An ArgLoader loading a Where builds its type with the TypeBuilder set by
+// WithTypeBuilder, and loads its condition values with its own loaders.
+type Where[T any] struct {
+	Filter
+}
+
+// UnmarshalGraphQLArg implements ArgUnmarshaler.  It uses the default arg loader.
+func (w *Where[T]) UnmarshalGraphQLArg(i interface{}) error {
+	return w.unmarshalArgWith(defaultLoader, i)
+}
+
+// GraphQLInputType implements ArgUnmarshaler.  It uses the default type builder, and panics if the
+// type can't be built.
+func (w *Where[T]) GraphQLInputType() graphql.Input {
+	t := typeOf[T]()
+	return defaultTypeBuilder.FilterInput(outputTypeName(t)+"Filter", t)
+}
+
+func (w *Where[T]) unmarshalArgWith(e *ArgLoader, i interface{}) error {
+	f, err := e.LoadFilter(typeOf[T](), i)
+	if err != nil {
+		return err
+	}
+	w.Filter = f
+	return nil
+}
+
+func (w *Where[T]) inputTypeWith(e *ArgLoader) (graphql.Input, error) {
+	t := typeOf[T]()
+	obj, err := e.types().SafeFilterInput(outputTypeName(t)+"Filter", t)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Match reports whether item matches the filter.
+func (w Where[T]) Match(item T) bool {
+	return w.Filter.Match(item)
+}
+
+// plainValue returns the value held by v, following pointers and unwrapping nullable wrappers like
+// null.String.  isNull is true for nil pointers, slices and maps, and invalid wrappers.
+func plainValue(v reflect.Value) (value interface{}, isNull bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, true
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, true
+	}
+	if nullWrapper(v.Type()) {
+		value, err := v.Interface().(driver.Valuer).Value()
+		if err != nil || value == nil {
+			return nil, true
+		}
+		return value, false
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil, true
+	}
+	return v.Interface(), false
+}
+
+// equalValues reports whether a and b are equal, comparing numbers of different types by value.
+func equalValues(a, b interface{}) bool {
+	if n, ok := compareValues(a, b); ok {
+		return n == 0
+	}
+	a, _ = plainValue(reflect.ValueOf(a))
+	b, _ = plainValue(reflect.ValueOf(b))
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues orders two numbers, strings, bools or times.  ok is false if they can't be
+// compared.
+func compareValues(a, b interface{}) (n int, ok bool) {
+	a, _ = plainValue(reflect.ValueOf(a))
+	b, _ = plainValue(reflect.ValueOf(b))
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+		switch {
+		case !ok:
+			return 0, false
+		case at.Before(bt):
+			return -1, true
+		case at.After(bt):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !av.IsValid() || !bv.IsValid() {
+		return 0, false
+	}
+	switch ak, bk := kindClass(av.Kind()), kindClass(bv.Kind()); {
+	case ak == reflect.Int && bk == reflect.Int:
+		return order(av.Int() < bv.Int(), av.Int() > bv.Int()), true
+	case ak == reflect.Uint && bk == reflect.Uint:
+		return order(av.Uint() < bv.Uint(), av.Uint() > bv.Uint()), true
+	case isNumberClass(ak) && isNumberClass(bk):
+		af, bf := toFloat(av), toFloat(bv)
+		return order(af < bf, af > bf), true
+	case ak == reflect.String && bk == reflect.String:
+		return strings.Compare(av.String(), bv.String()), true
+	case ak == reflect.Bool && bk == reflect.Bool:
+		return order(!av.Bool() && bv.Bool(), av.Bool() && !bv.Bool()), true
+	}
+	return 0, false
+}
+
+// kindClass groups the sized kinds of numbers under Int, Uint and Float64.
+func kindClass(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return k
+}
+
+func isNumberClass(k reflect.Kind) bool {
+	return k == reflect.Int || k == reflect.Uint || k == reflect.Float64
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch kindClass(v.Kind()) {
+	case reflect.Int:
+		return float64(v.Int())
+	case reflect.Uint:
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+func order(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,313 @@
+package sugar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFilterBase struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type testFilterPet struct {
+	testFilterBase
+	Name    string      `json:"name" desc:"The pet's name"`
+	Nick    null.String `json:"nick"`
+	Weight  float64     `json:"weight"`
+	Born    time.Time   `json:"born"`
+	Good    bool        `json:"good"`
+	Tags    []string    `json:"tags"`
+	private string
+}
+
+type testFilterArgs struct {
+	Where Where[testFilterPet] `arg:"where"`
+}
+
+var testFilterPets = []testFilterPet{
+	{testFilterBase: testFilterBase{ID: 1}, Name: "Rex", Nick: null.StringFrom("T-Rex"), Weight: 30, Good: true},
+	{testFilterBase: testFilterBase{ID: 2}, Name: "Tom", Weight: 4.5, Good: false},
+	{testFilterBase: testFilterBase{ID: 3}, Name: "Fido", Nick: null.StringFrom("Fi"), Weight: 12, Good: true},
+}
+
+func TestFilterInput(t *testing.T) {
+	tb := NewTypeBuilder()
+	filter := tb.FilterInput("PetFilter", testFilterPet{})
+	assert.Equal(t, filter, tb.FilterInput("PetFilter", &testFilterPet{}))
+
+	fields := filter.Fields()
+	assert.Len(t, fields, 9)
+	assert.Equal(t, "IntFilter", fields["id"].Type.Name())
+	assert.Equal(t, "StringFilter", fields["name"].Type.Name())
+	assert.Equal(t, "The pet's name", fields["name"].Description())
+	assert.Equal(t, "StringFilter", fields["nick"].Type.Name())
+	assert.Equal(t, "TimestampFilter", fields["born"].Type.Name())
+	assert.Equal(t, "[PetFilter!]", fields["and"].Type.String())
+	assert.Equal(t, "PetFilter", fields["not"].Type.String())
+	assert.NotContains(t, fields, "tags")
+
+	stringOps := fields["name"].Type.(*graphql.InputObject).Fields()
+	assert.Len(t, stringOps, 5)
+	assert.Equal(t, "[String!]", stringOps["in"].Type.String())
+	assert.Equal(t, "Boolean", stringOps["isNull"].Type.String())
+
+	boolOps := fields["good"].Type.(*graphql.InputObject).Fields()
+	assert.Len(t, boolOps, 3)
+	assert.NotContains(t, boolOps, "in")
+
+	intOps := fields["id"].Type.(*graphql.InputObject).Fields()
+	assert.Contains(t, intOps, "lt")
+	assert.NotContains(t, intOps, "contains")
+}
+
+type testFilterReserved struct {
+	Name string `json:"name"`
+	Not  bool   `json:"not"`
+}
+
+type testFilterHidden struct {
+	Secret string `json:"secret"`
+}
+
+type testFilterEmbedsHidden struct {
+	*testFilterHidden
+	Name string `json:"name"`
+}
+
+func TestFilterInputErrors(t *testing.T) {
+	tb := NewTypeBuilder()
+	_, err := tb.SafeFilterInput("ReservedFilter", testFilterReserved{})
+	assert.EqualError(t, err, "cannot filter sugar.testFilterReserved: the name of its not field is used to combine filters")
+	assert.Panics(t, func() { tb.FilterInput("ReservedFilter", testFilterReserved{}) })
+	_, err = LoadFilter(testFilterReserved{}, map[string]interface{}{"not": map[string]interface{}{}})
+	if assert.NotNil(t, err) {
+		_, isArgErrors := err.(ArgErrors)
+		assert.False(t, isArgErrors)
+	}
+
+	// a name can't be reused for a filter of another type, or for the operators of a leaf type.
+	_, err = tb.SafeFilterInput("PetFilter", testFilterPet{})
+	assert.Nil(t, err)
+	_, err = tb.SafeFilterInput("PetFilter", testConnPet{})
+	assert.EqualError(t, err, "the GraphQL type name PetFilter is already used for a filter of sugar.testFilterPet")
+	_, err = tb.SafeFilterInput("StringFilter", testConnPet{})
+	assert.EqualError(t, err, "the GraphQL type name StringFilter is already used for the filter operators for String")
+
+	// embedded pointers to unexported structs are skipped, like encoding/json does.
+	filter, err := tb.SafeFilterInput("EmbedsHiddenFilter", testFilterEmbedsHidden{})
+	assert.Nil(t, err)
+	assert.NotContains(t, filter.Fields(), "secret")
+	assert.Contains(t, filter.Fields(), "name")
+}
+
+func TestLoadFilter(t *testing.T) {
+	f, err := LoadFilter(testFilterPet{}, map[string]interface{}{
+		"name": map[string]interface{}{"contains": "o", "ne": "Tom"},
+		"or": []interface{}{
+			map[string]interface{}{"id": map[string]interface{}{"in": []interface{}{1, 3}}},
+			map[string]interface{}{"nick": map[string]interface{}{"isNull": true}},
+		},
+		"not": map[string]interface{}{"weight": map[string]interface{}{"gt": 20.0}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, Filter{
+		Conditions: []Condition{
+			{Field: "name", Op: FilterContains, Value: "o"},
+			{Field: "name", Op: FilterNe, Value: "Tom"},
+		},
+		Or: []Filter{
+			{Conditions: []Condition{{Field: "id", Op: FilterIn, Value: []int{1, 3}}}},
+			{Conditions: []Condition{{Field: "nick", Op: FilterIsNull, Value: true}}},
+		},
+		Not: &Filter{Conditions: []Condition{{Field: "weight", Op: FilterGt, Value: 20.0}}},
+	}, f)
+	assert.Equal(t, []testFilterPet{testFilterPets[2]}, FilterSlice(testFilterPets, f))
+
+	// the loaders for condition values are compiled once.
+	_, ok := defaultLoader.reg.Load().filterLoaders.Load(reflect.TypeOf(0))
+	assert.True(t, ok)
+
+	_, err = LoadFilter(testFilterPet{}, map[string]interface{}{
+		"id":  map[string]interface{}{"eq": "one"},
+		"and": []interface{}{map[string]interface{}{"nope": map[string]interface{}{"eq": "x"}}},
+	})
+	if assert.IsType(t, ArgErrors{}, err) {
+		errs := err.(ArgErrors)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, "and[0].nope", errs[0].Path)
+		assert.Equal(t, "id.eq", errs[1].Path)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	born := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pet := testFilterPet{Name: "Rex", Weight: 30, Born: born}
+
+	tests := []struct {
+		c     Condition
+		match bool
+	}{
+		{Condition{Field: "name", Op: FilterEq, Value: "Rex"}, true},
+		{Condition{Field: "name", Op: FilterIn, Value: []string{"Tom", "Fido"}}, false},
+		{Condition{Field: "weight", Op: FilterLt, Value: 31}, true},
+		{Condition{Field: "born", Op: FilterGt, Value: born.Add(-time.Hour)}, true},
+		{Condition{Field: "born", Op: FilterLt, Value: born}, false},
+		{Condition{Field: "nick", Op: FilterIsNull, Value: true}, true},
+		// a null field only matches isNull.
+		{Condition{Field: "nick", Op: FilterNe, Value: "x"}, false},
+		{Condition{Field: "nope", Op: FilterEq, Value: "x"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s %v", tt.c.Field, tt.c.Op, tt.c.Value), func(t *testing.T) {
+			assert.Equal(t, tt.match, Filter{Conditions: []Condition{tt.c}}.Match(&pet))
+		})
+	}
+	assert.True(t, Filter{}.Match(pet))
+}
+
+// testSQLBackend translates filters into SQL-ish strings.
+type testSQLBackend struct{}
+
+func (testSQLBackend) Condition(c Condition) (string, error) {
+	switch c.Op {
+	case FilterEq:
+		return fmt.Sprintf("%s = %v", c.Field, c.Value), nil
+	case FilterGt:
+		return fmt.Sprintf("%s > %v", c.Field, c.Value), nil
+	}
+	return "", fmt.Errorf("%s is not supported", c.Op)
+}
+
+func (testSQLBackend) And(qs []string) string {
+	if len(qs) == 0 {
+		return "TRUE"
+	}
+	if len(qs) == 1 {
+		return qs[0]
+	}
+	return "(" + strings.Join(qs, " AND ") + ")"
+}
+
+func (testSQLBackend) Or(qs []string) string {
+	return "(" + strings.Join(qs, " OR ") + ")"
+}
+
+func (testSQLBackend) Not(q string) string {
+	return "NOT " + q
+}
+
+func TestTranslateFilter(t *testing.T) {
+	q, err := TranslateFilter[string](Filter{
+		Conditions: []Condition{{Field: "id", Op: FilterGt, Value: 1}},
+		Or: []Filter{
+			{Conditions: []Condition{{Field: "name", Op: FilterEq, Value: "Rex"}}},
+			{Conditions: []Condition{{Field: "name", Op: FilterEq, Value: "Tom"}}},
+		},
+		Not: &Filter{Conditions: []Condition{{Field: "good", Op: FilterEq, Value: false}}},
+	}, testSQLBackend{})
+	assert.Nil(t, err)
+	assert.Equal(t, "(id > 1 AND (name = Rex OR name = Tom) AND NOT good = false)", q)
+
+	_, err = TranslateFilter[string](Filter{Conditions: []Condition{{Field: "name", Op: FilterContains, Value: "R"}}}, testSQLBackend{})
+	assert.NotNil(t, err)
+}
+
+func TestWhereArg(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"pets": &graphql.Field{
+					Type: graphql.NewList(graphql.String),
+					Args: ArgsConfig(testFilterArgs{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testFilterArgs{}
+						if err := LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						names := []string{}
+						for _, pet := range testFilterPets {
+							if args.Where.Match(pet) {
+								names = append(names, pet.Name)
+							}
+						}
+						return names, nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ pets(where: {good: {eq: true}, weight: {lt: 20}}) }`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{"pets": []interface{}{"Fido"}}, result.Data)
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ pets(where: {or: [{name: {eq: "Tom"}}, {nick: {contains: "Rex"}}]}) }`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{"pets": []interface{}{"Rex", "Tom"}}, result.Data)
+}
+
+type testFilterCode string
+
+type testFilterCodeItem struct {
+	Code testFilterCode `json:"code"`
+}
+
+type testFilterCodeArgs struct {
+	Where Where[testFilterCodeItem] `arg:"where"`
+}
+
+func TestWhereWithLoader(t *testing.T) {
+	tb := NewTypeBuilder()
+	loader, err := New(WithTypeBuilder(tb))
+	assert.Nil(t, err)
+	assert.Nil(t, loader.RegisterArgParser(func(i interface{}) (testFilterCode, error) {
+		s, _ := i.(string)
+		return testFilterCode(strings.ToUpper(s)), nil
+	}, graphql.String))
+
+	// the type is built with the loader's type builder.
+	conf, err := loader.SafeArgsConfig(testFilterCodeArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, tb.FilterInput("TestFilterCodeItemFilter", testFilterCodeItem{}), conf["where"].Type)
+
+	// and the values with the loader's loaders.
+	args := testFilterCodeArgs{}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"where": map[string]interface{}{"code": map[string]interface{}{"eq": "ab"}},
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, []Condition{{Field: "code", Op: FilterEq, Value: testFilterCode("AB")}}, args.Where.Conditions)
+}
+
+type FilterCycleB struct {
+	*FilterCycleA
+	B int `json:"b"`
+}
+
+type FilterCycleA struct {
+	*FilterCycleB
+	A int `json:"a"`
+}
+
+func TestFilterEmbeddingCycle(t *testing.T) {
+	filter, err := NewTypeBuilder().SafeFilterInput("CycleFilter", FilterCycleA{})
+	assert.Nil(t, err)
+	assert.Contains(t, filter.Fields(), "a")
+	assert.Contains(t, filter.Fields(), "b")
+}
@@ -0,0 +1,63 @@
+package sugar
+
+import (
+	"reflect"
+	"runtime"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Register adds f to the ArgLoader as the loader for T, with gqlType as the GraphQL type for T
+// arguments.  It's the type-checked equivalent of RegisterArgParser.
+func Register[T any](e *ArgLoader, f func(any) (T, error), gqlType graphql.Input) error {
+	return registerFunc(e, withoutOptions(f), gqlType, funcName(f))
+}
+
+// RegisterWithOptions is like Register, but f is also passed the options from the tag of the field
+// being loaded.
+func RegisterWithOptions[T any](e *ArgLoader, f func(any, TagOptions) (T, error), gqlType graphql.Input) error {
+	return registerFunc(e, f, gqlType, funcName(f))
+}
+
+// registerFunc wraps f as a loader for T.  fname identifies f in error messages.
+func registerFunc[T any](e *ArgLoader, f func(any, TagOptions) (T, error), gqlType graphql.Input, fname string) error {
+	return e.register(typeOf[T](), wrapLoader(f, fname), gqlType, fname)
+}
+
+// Load returns a T with its tagged fields loaded from the ResolveParams' arguments.  It uses the
+// default arg loader.
+func Load[T any](p graphql.ResolveParams) (T, error) {
+	return LoadWith[T](defaultLoader, p)
+}
+
+// LoadWith returns a T with its tagged fields loaded from the ResolveParams' arguments by the given
+// ArgLoader.
+func LoadWith[T any](e *ArgLoader, p graphql.ResolveParams) (T, error) {
+	var args T
+	err := e.LoadArgs(p, &args)
+	return args, err
+}
+
+// Args returns the argument configs for the tagged fields of T, for assigning to the Args field in
+// a graphql.Field.  Like ArgsConfig, it panics if they cannot be generated.  It uses the default arg
+// loader.
+func Args[T any]() graphql.FieldConfigArgument {
+	return ArgsWith[T](defaultLoader)
+}
+
+// ArgsWith returns the argument configs for the tagged fields of T, as described by the given
+// ArgLoader.  It panics if they cannot be generated.
+func ArgsWith[T any](e *ArgLoader) graphql.FieldConfigArgument {
+	var args T
+	return e.ArgsConfig(&args)
+}
+
+// funcName returns the name of the given func, for error messages.
+func funcName(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// typeOf returns the reflect.Type for T, even when T is an interface type.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,65 @@
+package sugar
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPoint struct {
+	X, Y int
+}
+
+type testPointArgs struct {
+	From testPoint  `arg:"from,required"`
+	To   *testPoint `arg:"to"`
+}
+
+func loadTestPoint(i any) (testPoint, error) {
+	xy, ok := i.([]interface{})
+	if !ok || len(xy) != 2 {
+		return testPoint{}, errors.New("a point needs two coordinates")
+	}
+	x, xok := xy[0].(int)
+	y, yok := xy[1].(int)
+	if !xok || !yok {
+		return testPoint{}, errors.New("coordinates must be ints")
+	}
+	return testPoint{X: x, Y: y}, nil
+}
+
+func TestGenerics(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	assert.Nil(t, Register(loader, loadTestPoint, graphql.NewList(graphql.Int)))
+	assert.NotNil(t, Register(loader, loadTestPoint, graphql.NewList(graphql.Int)))
+
+	conf := ArgsWith[testPointArgs](loader)
+	assert.Equal(t, "[Int]!", conf["from"].Type.String())
+	assert.Equal(t, "[Int]", conf["to"].Type.String())
+
+	args, err := LoadWith[testPointArgs](loader, graphql.ResolveParams{Args: map[string]interface{}{
+		"from": []interface{}{1, 2},
+		"to":   []interface{}{3, 4},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, testPointArgs{From: testPoint{1, 2}, To: &testPoint{3, 4}}, args)
+
+	_, err = LoadWith[testPointArgs](loader, graphql.ResolveParams{Args: map[string]interface{}{
+		"from": []interface{}{1},
+	}})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "from is not valid: a point needs two coordinates")
+	}
+}
+
+func TestGenericsDefaultLoader(t *testing.T) {
+	conf := Args[testRequiredArgs]()
+	assert.Equal(t, "String!", conf["id"].Type.String())
+
+	args, err := Load[testRequiredArgs](graphql.ResolveParams{Args: map[string]interface{}{"id": "bob"}})
+	assert.Nil(t, err)
+	assert.Equal(t, testRequiredArgs{ID: "bob"}, args)
+}
@@ -0,0 +1,146 @@
+package sugar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// InputType builds a GraphQL input type for you from the given name, desc, and val, the same way
+// OutputType builds output types.  Struct fields are read from their json tags, so one struct can
+// describe both the object a query returns and the input object a mutation accepts.  Nested
+// structs become input objects named after their Go type, with an "Input" suffix.  Known types are
+// used where they're scalars or enums.
+//
+// Pair it with ArgLoader.RegisterInputType to load arguments of the new type back into val's type.
+func (tb *TypeBuilder) InputType(name, desc string, val interface{}) graphql.Input {
+	// types that have been built before can be returned without locking.
+	if inputType, ok := (*tb.inputTypes.Load())[getType(val)]; ok {
+		return inputType
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.buildingInputs = tb.inputTypes.Load().clone()
+	defer func() { tb.buildingInputs = nil }()
+	t := tb.inputType(name, desc, getType(val))
+	types := tb.buildingInputs
+	tb.inputTypes.Store(&types)
+	return t
+}
+
+// inputType does the work of InputType.  The caller must hold mu, and have set up
+// tb.buildingInputs.
+func (tb *TypeBuilder) inputType(name, desc string, t reflect.Type) graphql.Input {
+	if inputType, ok := tb.buildingInputs[t]; ok {
+		return inputType
+	}
+	if knownType, ok := (*tb.knownTypes.Load())[t]; ok && graphql.IsInputType(knownType) {
+		return knownType
+	}
+	if scalar, ok := kindScalar(t.Kind()); ok {
+		return scalar
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return tb.inputType(name, desc, t.Elem())
+	case reflect.Slice, reflect.Array:
+		return graphql.NewList(tb.inputType(name, desc, t.Elem()))
+	case reflect.Struct:
+		// graphql-go reads the field map lazily, so we can remember the object before filling in
+		// its fields.  That lets self-referencing structs point back at it.
+		fields := graphql.InputObjectConfigFieldMap{}
+		obj := graphql.NewInputObject(graphql.InputObjectConfig{
+			Name:        name,
+			Description: desc,
+			Fields:      fields,
+		})
+		if err := checkTypeName(obj, t); err != nil {
+			panic(err)
+		}
+		tb.buildingInputs[t] = obj
+		tb.inputFieldMap(t, fields)
+		return obj
+	default:
+		panic(fmt.Sprintf("cannot convert %v kind", t.Kind()))
+	}
+}
+
+// inputFieldMap adds the json-tagged fields of a struct type to fields, flattening embedded
+// structs like structFieldMap does.  The caller must hold mu.
+func (tb *TypeBuilder) inputFieldMap(structType reflect.Type, fields graphql.InputObjectConfigFieldMap) {
+	for _, f := range jsonFields(structType) {
+		desc := f.field.Tag.Get("desc")
+		fields[f.name] = &graphql.InputObjectFieldConfig{
+			Type:        tb.inputType(inputTypeName(f.field.Type, f.name), desc, f.field.Type),
+			Description: desc,
+		}
+	}
+}
+
+// inputTypeName names the input object for a nested struct after its Go type, or after the field
+// that holds it if it's anonymous.
+func inputTypeName(t reflect.Type, fieldName string) string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	name := t.Name()
+	if name == "" {
+		name = fieldName
+	}
+	return strings.ToUpper(name[:1]) + name[1:] + "Input"
+}
+
+// RegisterInputType registers a loader for the Go type of val, which must be a struct, using
+// gqlType as its GraphQL type.  gqlType will usually come from TypeBuilder.InputType.  Fields are
+// loaded by their json tags, with the ArgLoader's loaders, so the input map that graphql-go
+// provides loads back into the struct it was described from.
+func (e *ArgLoader) RegisterInputType(val interface{}, gqlType graphql.Input) error {
+	t := getType(val)
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot register an input type for %v: it is not a struct", t)
+	}
+	loader := func(i interface{}, config TagOptions) (reflect.Value, error) {
+		args, ok := i.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%v is not an input object", i)
+		}
+		v := reflect.New(t).Elem()
+		valErrs := ArgErrors{}
+		if err := e.jsonLoader().loadStruct(args, v, "", &valErrs); err != nil {
+			return reflect.Value{}, err
+		}
+		if len(valErrs) > 0 {
+			return reflect.Value{}, valErrs
+		}
+		return v, nil
+	}
+	return e.register(t, loader, gqlType, fmt.Sprintf("input type %v", gqlType))
+}
+
+// a jsonView is a copy of an ArgLoader that reads json tags instead of arg tags, along with the
+// registry it was copied from.
+type jsonView struct {
+	reg    *argRegistry
+	loader *ArgLoader
+}
+
+// jsonLoader returns a copy of e that reads json tags, for loading structs registered with
+// RegisterInputType.  It's made again whenever something is registered on e, so that it has the
+// same loaders.
+func (e *ArgLoader) jsonLoader() *ArgLoader {
+	reg := e.reg.Load()
+	if view := e.jsonView.Load(); view != nil && view.reg == reg {
+		return view.loader
+	}
+	loader := e.Clone()
+	loader.tag = "json"
+	loader.separator = defaultSeparator
+	loader.assignor = defaultAssignor
+	loader.nameMapper = func(fieldName string) string { return fieldName }
+	e.jsonView.Store(&jsonView{reg: reg, loader: loader})
+	return loader
+}
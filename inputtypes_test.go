@@ -0,0 +1,121 @@
+package sugar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAudit struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type testPet struct {
+	Name string `json:"name"`
+}
+
+type testUser struct {
+	testAudit
+	ID       int       `json:"id,omitempty" desc:"The user's ID."`
+	Name     string    `json:"name"`
+	Born     time.Time `json:"born"`
+	Pets     []testPet `json:"pets"`
+	BestPet  *testPet  `json:"bestPet"`
+	Manager  *testUser `json:"manager"`
+	Password string    `json:"-"`
+	internal string
+}
+
+func TestInputType(t *testing.T) {
+	tb := NewTypeBuilder()
+	obj, ok := tb.InputType("UserInput", "A user.", testUser{}).(*graphql.InputObject)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "UserInput", obj.Name())
+	fields := obj.Fields()
+	assert.Len(t, fields, 7)
+	assert.Equal(t, "Int", fields["id"].Type.String())
+	assert.Equal(t, "The user's ID.", fields["id"].Description())
+	assert.Equal(t, "Timestamp", fields["born"].Type.String())
+	assert.Equal(t, "[TestPetInput]", fields["pets"].Type.String())
+	assert.Equal(t, "TestPetInput", fields["bestPet"].Type.String())
+	assert.Equal(t, obj, fields["manager"].Type)
+	assert.Equal(t, "String", fields["createdBy"].Type.String())
+
+	// input types are cached separately from output types.
+	assert.Equal(t, obj, tb.InputType("Other", "", &testUser{}))
+	assert.IsType(t, &graphql.Object{}, tb.OutputType("Pet", "", testPet{}))
+	assert.IsType(t, &graphql.InputObject{}, tb.InputType("Other", "", testPet{}))
+}
+
+type testSaveUserInputArgs struct {
+	Input testUser `arg:"input,required"`
+}
+
+func TestRegisterInputType(t *testing.T) {
+	tb := NewTypeBuilder()
+	loader, err := New()
+	assert.Nil(t, err)
+	assert.Nil(t, loader.RegisterInputType(testUser{}, tb.InputType("UserInput", "", testUser{})))
+	assert.NotNil(t, loader.RegisterInputType("", graphql.String))
+
+	conf, err := loader.SafeArgsConfig(testSaveUserInputArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, "UserInput!", conf["input"].Type.String())
+
+	var saved testUser
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"saveUser": &graphql.Field{
+					Type: graphql.Boolean,
+					Args: conf,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testSaveUserInputArgs{}
+						if err := loader.LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						saved = args.Input
+						return true, nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `query($input: UserInput!) { saveUser(input: $input) }`,
+		VariableValues: map[string]interface{}{"input": map[string]interface{}{
+			"id":        1,
+			"name":      "bob",
+			"born":      "2000-01-02T03:04:05Z",
+			"createdBy": "admin",
+			"pets":      []interface{}{map[string]interface{}{"name": "rex"}},
+			"manager":   map[string]interface{}{"name": "ann"},
+		}},
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, testUser{
+		testAudit: testAudit{CreatedBy: "admin"},
+		ID:        1,
+		Name:      "bob",
+		Born:      time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC),
+		Pets:      []testPet{{Name: "rex"}},
+		Manager:   &testUser{Name: "ann"},
+	}, saved)
+
+	// problems inside the input are reported by their full path.
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"input": map[string]interface{}{"name": 5, "pets": []interface{}{map[string]interface{}{"name": true}}},
+	}}, &testSaveUserInputArgs{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "input.name is not valid")
+		assert.Contains(t, err.Error(), "input.pets[0].name is not valid")
+	}
+}
@@ -0,0 +1,84 @@
+package sugar
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// maxSafeFloatInt is the largest integer that a float64, and so a number in JSON variables, holds
+// exactly.
+const maxSafeFloatInt = 1 << 53
+
+func longSerialize(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.String:
+		return longParseValue(v.String())
+	}
+	return nil
+}
+
+// longParseValue returns the decimal string for an integer given as a string or a number, or nil
+// if it isn't one that fits in an int64 or uint64.  Numbers in JSON variables arrive as float64s,
+// so they're only accepted when they're whole and small enough to be exact.
+func longParseValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case string:
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return value
+		}
+		if _, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return value
+		}
+		return nil
+	case json.Number:
+		return longParseValue(string(value))
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) > maxSafeFloatInt {
+			return nil
+		}
+		return strconv.FormatInt(int64(value), 10)
+	}
+	return nil
+}
+
+func longParseLiteral(valueAST ast.Value) interface{} {
+	switch valueAST := valueAST.(type) {
+	case *ast.IntValue:
+		return longParseValue(valueAST.Value)
+	case *ast.StringValue:
+		return longParseValue(valueAST.Value)
+	}
+	return nil
+}
+
+// Long is a custom scalar for integers that may not fit in GraphQL's 32-bit Int.  It's sent as a
+// decimal string, and accepts a string or a number.
+var Long = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "Long",
+	Description:  "Long is a 64-bit integer, sent as a decimal string. It accepts a string or a number, but numbers larger than 2^53 should be sent as strings to avoid losing precision.",
+	Serialize:    longSerialize,
+	ParseValue:   longParseValue,
+	ParseLiteral: longParseLiteral,
+})
@@ -0,0 +1,106 @@
+package sugar
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNumericArgs struct {
+	I8  int8    `arg:"i8"`
+	I16 int16   `arg:"i16"`
+	I32 int32   `arg:"i32"`
+	I64 int64   `arg:"i64"`
+	U8  uint8   `arg:"u8"`
+	U16 uint16  `arg:"u16"`
+	U32 uint32  `arg:"u32"`
+	U64 uint64  `arg:"u64"`
+	U   uint    `arg:"u"`
+	F32 float32 `arg:"f32"`
+}
+
+func TestSafeArgsConfigNumeric(t *testing.T) {
+	conf, err := SafeArgsConfig(testNumericArgs{})
+	assert.Nil(t, err)
+	for name, want := range map[string]string{
+		"i8": "Int", "i16": "Int", "i32": "Int", "i64": "Long",
+		"u8": "Int", "u16": "Int", "u32": "Long", "u64": "Long",
+		"u": "Long", "f32": "Float",
+	} {
+		assert.Equal(t, want, conf[name].Type.String(), name)
+	}
+}
+
+func TestLoadArgsNumeric(t *testing.T) {
+	args := testNumericArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"i8":  -128,
+		"i16": 32767,
+		"i32": -5,
+		"i64": "-9223372036854775808",
+		"u8":  255,
+		"u16": 65535,
+		"u32": "4294967295",
+		"u64": "18446744073709551615",
+		"u":   "4294967296",
+		"f32": 1.5,
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testNumericArgs{
+		I8: -128, I16: 32767, I32: -5, I64: -9223372036854775808,
+		U8: 255, U16: 65535, U32: 4294967295, U64: 18446744073709551615,
+		U: 4294967296, F32: 1.5,
+	}, args)
+
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"i8":  128,
+		"u8":  -1,
+		"i64": "9223372036854775808",
+		"u32": "4294967296",
+		"u64": "-1",
+		"u":   "-1",
+		"f32": 1e39,
+	}}, &args)
+	if assert.NotNil(t, err) {
+		for _, msg := range []string{
+			"128 is out of range for int8",
+			"-1 is out of range for uint8",
+			"9223372036854775808 is out of range for int64",
+			"4294967296 is out of range for uint32",
+			"-1 is out of range for uint64",
+			"u is not valid: -1 is out of range for uint\n",
+			"1e+39 is out of range for float32",
+		} {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+}
+
+func TestLong(t *testing.T) {
+	assert.Equal(t, "42", Long.ParseValue(42))
+	assert.Equal(t, "42", Long.ParseValue(42.0))
+	assert.Nil(t, Long.ParseValue(4.2))
+	assert.Nil(t, Long.ParseValue(1e300))
+	assert.Equal(t, "18446744073709551615", Long.ParseValue("18446744073709551615"))
+	assert.Nil(t, Long.ParseValue("18446744073709551616"))
+	assert.Nil(t, Long.ParseValue("twelve"))
+	assert.Equal(t, "-7", Long.ParseLiteral(&ast.IntValue{Value: "-7"}))
+	assert.Equal(t, "7", Long.ParseLiteral(&ast.StringValue{Value: "7"}))
+
+	assert.Equal(t, "18446744073709551615", Long.Serialize(uint64(18446744073709551615)))
+	n := int64(-3)
+	assert.Equal(t, "-3", Long.Serialize(&n))
+	assert.Nil(t, Long.Serialize(1.5))
+}
+
+func TestOutputTypeWide(t *testing.T) {
+	tb := NewTypeBuilder()
+	assert.Equal(t, graphql.Int, tb.OutputType("", "", int32(0)))
+	assert.Equal(t, graphql.Int, tb.OutputType("", "", uint16(0)))
+	assert.Equal(t, Long, tb.OutputType("", "", int64(0)))
+	assert.Equal(t, Long, tb.OutputType("", "", uint(0)))
+	assert.Equal(t, Long, tb.OutputType("", "", uint64(0)))
+	assert.Equal(t, graphql.Float, tb.OutputType("", "", float32(0)))
+}
@@ -0,0 +1,196 @@
+package sugar
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNullableArgs struct {
+	Count   null.Int        `arg:"count"`
+	Name    null.String     `arg:"name"`
+	Active  null.Bool       `arg:"active"`
+	Score   null.Float      `arg:"score"`
+	Since   null.Time       `arg:"since"`
+	Title   sql.NullString  `arg:"title"`
+	Total   sql.NullInt64   `arg:"total"`
+	Rank    sql.NullInt32   `arg:"rank"`
+	Level   sql.NullInt16   `arg:"level"`
+	Flags   sql.NullByte    `arg:"flags"`
+	Ratio   sql.NullFloat64 `arg:"ratio"`
+	Enabled sql.NullBool    `arg:"enabled"`
+	Created sql.NullTime    `arg:"created"`
+	Updated pq.NullTime     `arg:"updated"`
+}
+
+func TestLoadArgsNullable(t *testing.T) {
+	conf, err := SafeArgsConfig(testNullableArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 14)
+	assert.Equal(t, "Timestamp", conf["since"].Type.String())
+
+	when := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	args := testNullableArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"count":   1,
+		"name":    "bob",
+		"active":  true,
+		"score":   1.5,
+		"since":   "2020-03-04T05:06:07Z",
+		"title":   "boss",
+		"total":   "5000000000",
+		"rank":    3,
+		"level":   4,
+		"flags":   5,
+		"ratio":   2.5,
+		"enabled": false,
+		"created": when,
+		"updated": when,
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testNullableArgs{
+		Count:   null.IntFrom(1),
+		Name:    null.StringFrom("bob"),
+		Active:  null.BoolFrom(true),
+		Score:   null.FloatFrom(1.5),
+		Since:   null.TimeFrom(when),
+		Title:   sql.NullString{String: "boss", Valid: true},
+		Total:   sql.NullInt64{Int64: 5000000000, Valid: true},
+		Rank:    sql.NullInt32{Int32: 3, Valid: true},
+		Level:   sql.NullInt16{Int16: 4, Valid: true},
+		Flags:   sql.NullByte{Byte: 5, Valid: true},
+		Ratio:   sql.NullFloat64{Float64: 2.5, Valid: true},
+		Enabled: sql.NullBool{Bool: false, Valid: true},
+		Created: sql.NullTime{Time: when, Valid: true},
+		Updated: pq.NullTime{Time: when, Valid: true},
+	}, args)
+
+	// an explicit null clears a value that was already there.
+	args = testNullableArgs{Name: null.StringFrom("bob"), Count: null.IntFrom(1)}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"name": nil,
+	}}, &args)
+	assert.Nil(t, err)
+	assert.False(t, args.Name.Valid)
+	assert.True(t, args.Count.Valid)
+
+	// through variables, only one the client sent as null clears a value.  graphql-go gives the
+	// omitted ones a null value too.
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"patch": &graphql.Field{
+					Type: graphql.String,
+					Args: ArgsConfig(testNullableArgs{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testNullableArgs{Name: null.StringFrom("bob"), Title: sql.NullString{String: "boss", Valid: true}}
+						if err := LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						return fmt.Sprint(args.Name.Valid, args.Title.Valid), nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+	variables := map[string]interface{}{"name": nil}
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query($name: String, $title: String) { patch(name: $name, title: $title) }`,
+		VariableValues: variables,
+		Context:        WithVariables(context.Background(), variables),
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{"patch": "false true"}, result.Data)
+}
+
+// testNullableCode is a nullable wrapper like sql.NullString.
+type testNullableCode struct {
+	Code  string
+	Valid bool
+}
+
+func (c testNullableCode) Value() (driver.Value, error) {
+	if !c.Valid {
+		return nil, nil
+	}
+	return c.Code, nil
+}
+
+func (c *testNullableCode) Scan(value interface{}) error {
+	c.Code, c.Valid = value.(string)
+	return nil
+}
+
+// testCard has a Valid field, but isn't a nullable wrapper.
+type testCard struct {
+	Number string `arg:"number"`
+	Valid  bool   `arg:"valid"`
+}
+
+func TestRegisterArgParserNil(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	assert.Nil(t, loader.RegisterArgParser(func(i interface{}) (testNullableCode, error) {
+		if i == nil {
+			return testNullableCode{}, nil
+		}
+		return testNullableCode{Code: i.(string), Valid: true}, nil
+	}, graphql.String))
+
+	args := struct {
+		Code testNullableCode `arg:"code"`
+	}{Code: testNullableCode{Code: "X", Valid: true}}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"code": nil}}, &args)
+	assert.Nil(t, err)
+	assert.False(t, args.Code.Valid)
+
+	// a struct without a registered loader is never given a null, whatever its fields.
+	cardArgs := struct {
+		Card testCard `arg:"card"`
+	}{Card: testCard{Number: "4111", Valid: true}}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"card": nil}}, &cardArgs)
+	assert.Nil(t, err)
+	assert.Equal(t, testCard{Number: "4111", Valid: true}, cardArgs.Card)
+}
+
+type testNullableOutput struct {
+	Count null.Int       `json:"count"`
+	Title sql.NullString `json:"title"`
+}
+
+func TestOutputTypeNullable(t *testing.T) {
+	tb := NewTypeBuilder()
+	obj := tb.OutputType("TestNullableOutput", "", testNullableOutput{}).(*graphql.Object)
+	assert.Equal(t, "Long", obj.Fields()["count"].Type.Name())
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"thing": &graphql.Field{
+					Type: obj,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						// too large for GraphQL's Int.
+						return testNullableOutput{Count: null.IntFrom(5000000000)}, nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ thing { count title } }`})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"thing": map[string]interface{}{"count": "5000000000", "title": nil},
+	}, result.Data)
+}
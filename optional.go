@@ -0,0 +1,213 @@
+package sugar
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// OptionalState says whether an Optional argument was left out, sent as null, or given a value.
+type OptionalState int
+
+const (
+	// Undefined means the argument was left out.  It's the zero value.
+	Undefined OptionalState = iota
+	// Null means the argument was sent as an explicit null.
+	Null
+	// Value means the argument was given a value.
+	Value
+)
+
+// Optional holds an argument that may be left out, sent as null, or given a value, which is what a
+// PATCH-style mutation needs to tell "leave it alone" from "clear it".  LoadArgs and SafeArgsConfig
+// handle Optional[T] fields for any T they can handle, using T's GraphQL type.  Optional arguments
+// are never NonNull, even when required.
+//
+// graphql-go drops null arguments before resolvers see them, and gives variables that the client
+// left out the same null value as ones it sent as null.  So LoadArgs can only find an explicit null
+// given as a variable when the query is run with a context from WithVariables, which holds the
+// variables as the client sent them.  Without one, an argument given a null variable is Undefined.
+// Inside input objects, and in ResolveParams built by hand, a key with a nil value is a null.
+type Optional[T any] struct {
+	State OptionalState
+	Value T
+}
+
+// OptionalOf returns an Optional holding v.
+func OptionalOf[T any](v T) Optional[T] {
+	return Optional[T]{State: Value, Value: v}
+}
+
+// IsUndefined reports whether the argument was left out.
+func (o Optional[T]) IsUndefined() bool {
+	return o.State == Undefined
+}
+
+// IsNull reports whether the argument was sent as null.
+func (o Optional[T]) IsNull() bool {
+	return o.State == Null
+}
+
+// HasValue reports whether the argument was given a value.
+func (o Optional[T]) HasValue() bool {
+	return o.State == Value
+}
+
+// Get returns the argument's value, and whether it had one.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.State == Value
+}
+
+// OrElse returns the argument's value if it had one, or def otherwise.
+func (o Optional[T]) OrElse(def T) T {
+	if o.State == Value {
+		return o.Value
+	}
+	return def
+}
+
+// Apply sets *dst to the argument's value if it had one, or to T's zero value if it was null.  If
+// the argument was left out, *dst is left alone.  It reports whether *dst was changed.
+func (o Optional[T]) Apply(dst *T) bool {
+	switch o.State {
+	case Value:
+		*dst = o.Value
+	case Null:
+		var zero T
+		*dst = zero
+	default:
+		return false
+	}
+	return true
+}
+
+// ApplyPtr is like Apply, but for pointer fields: a null sets *dst to nil, and a value sets it to
+// point at a copy of the value.
+func (o Optional[T]) ApplyPtr(dst **T) bool {
+	switch o.State {
+	case Value:
+		v := o.Value
+		*dst = &v
+	case Null:
+		*dst = nil
+	default:
+		return false
+	}
+	return true
+}
+
+// optionalArg is implemented by pointers to Optionals, so that they can be loaded with reflection
+// without knowing their type parameter.
+type optionalArg interface {
+	optionalElem() reflect.Type
+	optionalValue() (interface{}, bool)
+	setNull()
+	setValue(v reflect.Value)
+}
+
+func (o *Optional[T]) optionalElem() reflect.Type {
+	return typeOf[T]()
+}
+
+func (o *Optional[T]) optionalValue() (interface{}, bool) {
+	return o.Value, o.State == Value
+}
+
+func (o *Optional[T]) setNull() {
+	*o = Optional[T]{State: Null}
+}
+
+func (o *Optional[T]) setValue(v reflect.Value) {
+	*o = Optional[T]{State: Value, Value: v.Interface().(T)}
+}
+
+var optionalArgType = reflect.TypeOf((*optionalArg)(nil)).Elem()
+
+// optionalElem returns T if t is an Optional[T].
+func optionalElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || !reflect.PtrTo(t).Implements(optionalArgType) {
+		return nil, false
+	}
+	return reflect.New(t).Interface().(optionalArg).optionalElem(), true
+}
+
+// optionalValue returns the value held by v if it's an Optional with a value, or v itself if it
+// isn't an Optional.
+func optionalValue(v reflect.Value) (reflect.Value, bool) {
+	if _, ok := optionalElem(v.Type()); !ok {
+		return v, true
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	i, ok := ptr.Interface().(optionalArg).optionalValue()
+	return reflect.ValueOf(i), ok
+}
+
+// loadOptional returns a valueLoader for the Optional type t, which holds elem values loaded by
+// loadElem.
+func loadOptional(t reflect.Type, loadElem valueLoader) valueLoader {
+	return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+		ptr := reflect.New(t)
+		opt := ptr.Interface().(optionalArg)
+		if i == nil {
+			opt.setNull()
+			return ptr.Elem(), nil
+		}
+		elem, err := loadElem(i, path, valErrs)
+		if err != nil || !elem.IsValid() {
+			return reflect.Value{}, err
+		}
+		opt.setValue(elem)
+		return ptr.Elem(), nil
+	}
+}
+
+// variablesKey is the context key for the variables stored by WithVariables.
+type variablesKey struct{}
+
+// WithVariables returns a copy of ctx holding the variables of a request as the client sent them,
+// before graphql-go fills in the ones that were left out.  Run queries with it as the Context in
+// graphql.Params, so that LoadArgs can tell an Optional or nullable argument given a variable sent
+// as null from one whose variable was left out.
+func WithVariables(ctx context.Context, variables map[string]interface{}) context.Context {
+	return context.WithValue(ctx, variablesKey{}, variables)
+}
+
+// explicitNulls returns p.Args, plus a nil entry for each argument that was given as a variable the
+// client sent as null.  graphql-go leaves those arguments out, just like ones that weren't given.
+// The client's variables come from p.Context, as stored by WithVariables.
+func explicitNulls(p graphql.ResolveParams) map[string]interface{} {
+	if len(p.Info.FieldASTs) == 0 || p.Info.FieldASTs[0] == nil || p.Context == nil {
+		return p.Args
+	}
+	sent, ok := p.Context.Value(variablesKey{}).(map[string]interface{})
+	if !ok {
+		return p.Args
+	}
+	var args map[string]interface{}
+	for _, argAST := range p.Info.FieldASTs[0].Arguments {
+		variable, ok := argAST.Value.(*ast.Variable)
+		if !ok || argAST.Name == nil || variable.Name == nil {
+			continue
+		}
+		if _, given := p.Args[argAST.Name.Value]; given {
+			continue
+		}
+		if value, sentNull := sent[variable.Name.Value]; !sentNull || value != nil {
+			continue
+		}
+		if args == nil {
+			args = make(map[string]interface{}, len(p.Args)+1)
+			for k, v := range p.Args {
+				args[k] = v
+			}
+		}
+		args[argAST.Name.Value] = nil
+	}
+	if args == nil {
+		return p.Args
+	}
+	return args
+}
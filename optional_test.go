@@ -0,0 +1,120 @@
+package sugar
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPatchArgs struct {
+	ID       string             `arg:"id,required"`
+	Nickname Optional[string]   `arg:"nickname,max:10"`
+	Age      Optional[int]      `arg:"age,required"`
+	Tags     Optional[[]string] `arg:"tags"`
+}
+
+func TestSafeArgsConfigOptional(t *testing.T) {
+	conf, err := SafeArgsConfig(testPatchArgs{Nickname: OptionalOf("bob")})
+	assert.Nil(t, err)
+	assert.Equal(t, "String", conf["nickname"].Type.String())
+	assert.Equal(t, "bob", conf["nickname"].DefaultValue)
+	// an Optional can always be null, so required doesn't make it NonNull.
+	assert.Equal(t, "Int", conf["age"].Type.String())
+	assert.Equal(t, "[String]", conf["tags"].Type.String())
+}
+
+func TestLoadArgsOptional(t *testing.T) {
+	args := testPatchArgs{}
+	err := LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"id":       "1",
+		"nickname": "bob",
+		"age":      nil,
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, OptionalOf("bob"), args.Nickname)
+	assert.True(t, args.Age.IsNull())
+	assert.True(t, args.Tags.IsUndefined())
+
+	// validators check the value inside.
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"id":       "1",
+		"nickname": "Robert Loblaw",
+	}}, &args)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "nickname must have a length of at most 10")
+		assert.Contains(t, err.Error(), "age is required")
+	}
+}
+
+func TestLoadArgsOptionalVariables(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"patch": &graphql.Field{
+					Type: graphql.String,
+					Args: ArgsConfig(testPatchArgs{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testPatchArgs{}
+						if err := LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						return fmt.Sprint(args.Nickname.State, args.Age.State, args.Tags.State), nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+
+	query := `query($nickname: String, $age: Int, $tags: [String]) { patch(id: "1", nickname: $nickname, age: $age, tags: $tags) }`
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		context   bool
+		want      string
+	}{
+		{"explicit null", map[string]interface{}{"nickname": nil, "age": 30}, true, fmt.Sprint(Null, Value, Undefined)},
+		// graphql-go gives omitted variables a null value too, but they were never sent.
+		{"omitted", map[string]interface{}{"age": 30}, true, fmt.Sprint(Undefined, Value, Undefined)},
+		// without the client's variables, a null can't be told from an omitted variable.
+		{"no context", map[string]interface{}{"nickname": nil, "age": 30}, false, fmt.Sprint(Undefined, Value, Undefined)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.context {
+				ctx = WithVariables(ctx, tt.variables)
+			}
+			result := graphql.Do(graphql.Params{
+				Schema:         schema,
+				RequestString:  query,
+				VariableValues: tt.variables,
+				Context:        ctx,
+			})
+			assert.Empty(t, result.Errors)
+			assert.Equal(t, map[string]interface{}{"patch": tt.want}, result.Data)
+		})
+	}
+}
+
+func TestOptionalApply(t *testing.T) {
+	name := "bob"
+	assert.False(t, Optional[string]{}.Apply(&name))
+	assert.Equal(t, "bob", name)
+	assert.True(t, OptionalOf("rob").Apply(&name))
+	assert.Equal(t, "rob", name)
+	assert.True(t, Optional[string]{State: Null}.Apply(&name))
+	assert.Equal(t, "", name)
+
+	ptr := &name
+	assert.True(t, Optional[string]{State: Null}.ApplyPtr(&ptr))
+	assert.Nil(t, ptr)
+	assert.True(t, OptionalOf("bob").ApplyPtr(&ptr))
+	assert.Equal(t, "bob", *ptr)
+
+	assert.Equal(t, "anon", Optional[string]{}.OrElse("anon"))
+}
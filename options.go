@@ -0,0 +1,71 @@
+package sugar
+
+import (
+	"unicode"
+)
+
+// An Option configures an ArgLoader when passed to New, Base, or Empty.
+type Option func(*ArgLoader)
+
+// WithTag sets the struct tag that names arguments and holds their options.  The default is "arg".
+func WithTag(tag string) Option {
+	return func(e *ArgLoader) {
+		e.tag = tag
+	}
+}
+
+// WithDescriptionTag sets the struct tag that holds argument descriptions.  The default is "desc".
+func WithDescriptionTag(tag string) Option {
+	return func(e *ArgLoader) {
+		e.descTag = tag
+	}
+}
+
+// WithSeparator sets the string that separates the argument name and options in the tag.  The
+// default is ",".
+func WithSeparator(separator string) Option {
+	return func(e *ArgLoader) {
+		e.separator = separator
+	}
+}
+
+// WithAssignor sets the string that separates an option's key from its value in the tag.  The
+// default is ":".
+func WithAssignor(assignor string) Option {
+	return func(e *ArgLoader) {
+		e.assignor = assignor
+	}
+}
+
+// WithNameMapper sets the func that derives an argument name from the Go field name, for tags that
+// leave the name empty.  By default the field name is used unchanged.  Pass CamelCase to get
+// "userID" from a UserID field.
+func WithNameMapper(mapper func(fieldName string) string) Option {
+	return func(e *ArgLoader) {
+		e.nameMapper = mapper
+	}
+}
+
+// WithTypeBuilder sets the TypeBuilder that builds the GraphQL types of arguments like Where and
+// OrderTerms, so that they use its known types and names.  The default is the default type
+// builder.
+func WithTypeBuilder(tb *TypeBuilder) Option {
+	return func(e *ArgLoader) {
+		e.typeBuilder = tb
+	}
+}
+
+// CamelCase converts an exported Go name to lower camel case, keeping initialisms together.
+// "UserID" becomes "userID", "ID" becomes "id", and "HTTPServer" becomes "httpServer".
+func CamelCase(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		// stop before the last capital of a leading run if a lowercase letter follows it, since
+		// that capital starts the next word.
+		if !unicode.IsUpper(runes[i]) || (i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,56 @@
+package sugar
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCamelCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"Name":       "name",
+		"UserID":     "userID",
+		"ID":         "id",
+		"HTTPServer": "httpServer",
+		"already":    "already",
+		"":           "",
+	} {
+		assert.Equal(t, out, CamelCase(in), in)
+	}
+}
+
+type testOptionsArgs struct {
+	UserID   string `gql:";required" about:"The user's ID."`
+	Limit    int    `gql:"max;default=50"`
+	Ignored  string `arg:"ignored"`
+	FullName string `gql:""`
+}
+
+func TestOptions(t *testing.T) {
+	loader, err := New(
+		WithTag("gql"),
+		WithDescriptionTag("about"),
+		WithSeparator(";"),
+		WithAssignor("="),
+		WithNameMapper(CamelCase),
+	)
+	assert.Nil(t, err)
+
+	conf, err := loader.SafeArgsConfig(testOptionsArgs{})
+	assert.Nil(t, err)
+	assert.Len(t, conf, 3)
+	assert.Equal(t, "String!", conf["userID"].Type.String())
+	assert.Equal(t, "The user's ID.", conf["userID"].Description)
+	assert.Equal(t, 50, conf["max"].DefaultValue)
+	assert.Contains(t, conf, "fullName")
+
+	args := testOptionsArgs{}
+	err = loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"userID":   "bob",
+		"fullName": "Bob Loblaw",
+		"ignored":  "x",
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, testOptionsArgs{UserID: "bob", Limit: 50, FullName: "Bob Loblaw"}, args)
+}
@@ -0,0 +1,238 @@
+package sugar
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/graphql-go/graphql"
+)
+
+// An OrderDirection says which way an OrderTerm sorts.
+type OrderDirection string
+
+// The directions an OrderTerm can sort in.
+const (
+	OrderAsc  OrderDirection = "ASC"
+	OrderDesc OrderDirection = "DESC"
+)
+
+// orderDirectionEnum is the GraphQL type for OrderDirection.
+var orderDirectionEnum = func() *graphql.Enum {
+	enum, err := Enum(OrderAsc, map[string]OrderDirection{"ASC": OrderAsc, "DESC": OrderDesc})
+	if err != nil {
+		panic(err)
+	}
+	return enum
+}()
+
+// UnmarshalGraphQLArg implements ArgUnmarshaler.
+func (d *OrderDirection) UnmarshalGraphQLArg(i interface{}) error {
+	switch v := i.(type) {
+	case OrderDirection:
+		i = string(v)
+	case nil:
+		*d = OrderAsc
+		return nil
+	}
+	s, ok := i.(string)
+	if !ok || (OrderDirection(s) != OrderAsc && OrderDirection(s) != OrderDesc) {
+		return fmt.Errorf("%v is not a valid OrderDirection", i)
+	}
+	*d = OrderDirection(s)
+	return nil
+}
+
+// GraphQLInputType implements ArgUnmarshaler.
+func (d *OrderDirection) GraphQLInputType() graphql.Input {
+	return orderDirectionEnum
+}
+
+// An OrderTerm is one entry in a list of sort orders, loaded from an argument of the type built by
+// OrderBy.
+type OrderTerm struct {
+	// Field is the name of the field to sort by, from its json tag.
+	Field     string
+	Direction OrderDirection
+}
+
+// OrderBy builds a list input type for sorting the struct type of val, like
+// [{field: NAME, direction: DESC}].  Only fields with both a json tag and a `sortable:"true"` tag
+// may be sorted by.  Their names are listed in an enum called name+"Field", in upper snake case, and
+// the list holds input objects called name.  The direction defaults to ASC.  Load arguments of the
+// new type with ArgLoader.LoadOrderBy, or use OrderTerms[T] for arguments.  OrderBy panics if the
+// type can't be built; see SafeOrderBy.
+func (tb *TypeBuilder) OrderBy(name string, val interface{}) graphql.Input {
+	list, err := tb.SafeOrderBy(name, val)
+	if err != nil {
+		panic(err)
+	}
+	return list
+}
+
+// SafeOrderBy is like OrderBy, but returns an error instead of panicking.  It fails if val has no
+// sortable fields, if two of them get the same enum value name, or if one of the names is already
+// used for another type built by a helper.
+func (tb *TypeBuilder) SafeOrderBy(name string, val interface{}) (graphql.Input, error) {
+	t := derefType(getType(val))
+	fields := sortableFields(t)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("cannot build an order by type for %v: it has no sortable fields", t)
+	}
+	valueNames := map[string]string{}
+	for _, f := range fields {
+		valueName := enumValueName(f.name)
+		if other, ok := valueNames[valueName]; ok {
+			return nil, fmt.Errorf("cannot build an order by type for %v: its fields %s and %s are both %s", t, other, f.name, valueName)
+		}
+		valueNames[valueName] = f.name
+	}
+
+	fieldEnum, err := tb.namedType(name+"Field", namedKey{"the sortable fields of", t}, func() graphql.Output {
+		values := graphql.EnumValueConfigMap{}
+		for _, f := range fields {
+			values[enumValueName(f.name)] = &graphql.EnumValueConfig{
+				Value:       f.name,
+				Description: f.field.Tag.Get("desc"),
+			}
+		}
+		return graphql.NewEnum(graphql.EnumConfig{
+			Name:        name + "Field",
+			Description: fmt.Sprintf("The fields that %s can sort by.", name),
+			Values:      values,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	term, err := tb.namedType(name, namedKey{"an order term of", t}, func() graphql.Output {
+		return graphql.NewInputObject(graphql.InputObjectConfig{
+			Name: name,
+			Fields: graphql.InputObjectConfigFieldMap{
+				"field": &graphql.InputObjectFieldConfig{
+					Type:        graphql.NewNonNull(fieldEnum.(*graphql.Enum)),
+					Description: "The field to sort by.",
+				},
+				"direction": &graphql.InputObjectFieldConfig{
+					Type:         orderDirectionEnum,
+					DefaultValue: OrderAsc,
+					Description:  "The direction to sort in.",
+				},
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return graphql.NewList(graphql.NewNonNull(term)), nil
+}
+
+// LoadOrderBy loads a list of OrderTerms from i, the value graphql-go provides for an argument of
+// the type that OrderBy builds for val.  Fields that aren't sortable are refused, and problems are
+// returned as ArgErrors.
+func (e *ArgLoader) LoadOrderBy(val interface{}, i interface{}) ([]OrderTerm, error) {
+	t := derefType(getType(val))
+	termsType := reflect.TypeOf([]OrderTerm{})
+	if i == nil {
+		return nil, nil
+	}
+	list, ok := i.([]interface{})
+	if !ok {
+		return nil, ArgErrors{newArgError(ErrCodeInvalidType, "", termsType, "", fmt.Errorf("%v is not a list", i))}
+	}
+
+	sortable := map[string]bool{}
+	for _, f := range sortableFields(t) {
+		sortable[f.name] = true
+	}
+	terms := make([]OrderTerm, 0, len(list))
+	valErrs := ArgErrors{}
+	for j, item := range list {
+		path := fmt.Sprintf("[%d]", j)
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			valErrs.add(newArgError(ErrCodeInvalidType, path, termsType.Elem(), "", fmt.Errorf("%v is not an input object", item)))
+			continue
+		}
+		term := OrderTerm{}
+		field, _ := m["field"].(string)
+		if !sortable[field] {
+			valErrs.add(newArgError(ErrCodeValidation, path+".field", reflect.TypeOf(field), "",
+				fmt.Errorf("%v is not a sortable field", m["field"])))
+			continue
+		}
+		term.Field = field
+		if err := term.Direction.UnmarshalGraphQLArg(m["direction"]); err != nil {
+			valErrs.add(newArgError(ErrCodeInvalidType, path+".direction", reflect.TypeOf(term.Direction), orderDirectionEnum.Name(), err))
+			continue
+		}
+		terms = append(terms, term)
+	}
+	if len(valErrs) > 0 {
+		return nil, valErrs
+	}
+	return terms, nil
+}
+
+// OrderTerms is an argument type for sorting Ts.  Its GraphQL type is the list that OrderBy builds
+// for T, with input objects named after T with an "Order" suffix, and it's loaded with
+// ArgLoader.LoadOrderBy.  An ArgLoader loading OrderTerms builds their type with the TypeBuilder
+// set by WithTypeBuilder.
+type OrderTerms[T any] []OrderTerm
+
+// UnmarshalGraphQLArg implements ArgUnmarshaler.  It uses the default arg loader.
+func (o *OrderTerms[T]) UnmarshalGraphQLArg(i interface{}) error {
+	return o.unmarshalArgWith(defaultLoader, i)
+}
+
+// GraphQLInputType implements ArgUnmarshaler.  It uses the default type builder, and panics if the
+// type can't be built.
+func (o *OrderTerms[T]) GraphQLInputType() graphql.Input {
+	t := typeOf[T]()
+	return defaultTypeBuilder.OrderBy(outputTypeName(t)+"Order", t)
+}
+
+func (o *OrderTerms[T]) unmarshalArgWith(e *ArgLoader, i interface{}) error {
+	terms, err := e.LoadOrderBy(typeOf[T](), i)
+	if err != nil {
+		return err
+	}
+	*o = terms
+	return nil
+}
+
+func (o *OrderTerms[T]) inputTypeWith(e *ArgLoader) (graphql.Input, error) {
+	t := typeOf[T]()
+	return e.types().SafeOrderBy(outputTypeName(t)+"Order", t)
+}
+
+// sortableFields returns the json-tagged fields of a struct type that are tagged sortable.
+func sortableFields(t reflect.Type) []jsonField {
+	fields := []jsonField{}
+	for _, f := range jsonFields(t) {
+		if f.field.Tag.Get("sortable") == "true" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// enumValueName converts a field name like "createdAt" into an enum value name like "CREATED_AT".
+func enumValueName(name string) string {
+	var b strings.Builder
+	prev := '_'
+	for _, r := range name {
+		switch {
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			b.WriteRune('_')
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			r = '_'
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prev = r
+	}
+	return b.String()
+}
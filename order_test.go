@@ -0,0 +1,157 @@
+package sugar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testOrderPet struct {
+	Name      string    `json:"name" sortable:"true" desc:"The pet's name"`
+	CreatedAt time.Time `json:"createdAt" sortable:"true"`
+	Tags      []string  `json:"tags"`
+}
+
+type testOrderArgs struct {
+	OrderBy OrderTerms[testOrderPet] `arg:"orderBy"`
+}
+
+func TestOrderBy(t *testing.T) {
+	tb := NewTypeBuilder()
+	list := tb.OrderBy("PetOrder", testOrderPet{})
+	assert.Equal(t, "[PetOrder!]", list.String())
+
+	term := list.(*graphql.List).OfType.(*graphql.NonNull).OfType.(*graphql.InputObject)
+	assert.Equal(t, term, tb.OrderBy("PetOrder", &testOrderPet{}).(*graphql.List).OfType.(*graphql.NonNull).OfType)
+	assert.Equal(t, "PetOrderField!", term.Fields()["field"].Type.String())
+	assert.Equal(t, "OrderDirection", term.Fields()["direction"].Type.String())
+
+	enum := term.Fields()["field"].Type.(*graphql.NonNull).OfType.(*graphql.Enum)
+	names := []string{}
+	for _, v := range enum.Values() {
+		names = append(names, v.Name)
+	}
+	assert.ElementsMatch(t, []string{"NAME", "CREATED_AT"}, names)
+	assert.Equal(t, "createdAt", enum.ParseValue("CREATED_AT"))
+
+	assert.Panics(t, func() { tb.OrderBy("BadOrder", testConnPet{}) })
+}
+
+type testBadOrderArgs struct {
+	OrderBy OrderTerms[testConnPet] `arg:"orderBy"`
+}
+
+type testOrderClash struct {
+	CreatedAt  time.Time `json:"createdAt" sortable:"true"`
+	CreatedAt2 time.Time `json:"created_at" sortable:"true"`
+}
+
+func TestOrderByErrors(t *testing.T) {
+	tb := NewTypeBuilder()
+	_, err := tb.SafeOrderBy("BadOrder", testConnPet{})
+	assert.EqualError(t, err, "cannot build an order by type for sugar.testConnPet: it has no sortable fields")
+
+	tb.FilterInput("PetOrder", testFilterPet{})
+	_, err = tb.SafeOrderBy("PetOrder", testOrderPet{})
+	assert.EqualError(t, err, "the GraphQL type name PetOrder is already used for a filter of sugar.testFilterPet")
+
+	_, err = tb.SafeOrderBy("ClashOrder", testOrderClash{})
+	assert.EqualError(t, err, "cannot build an order by type for sugar.testOrderClash: its fields createdAt and created_at are both CREATED_AT")
+
+	// arguments report it instead of panicking.
+	_, err = SafeArgsConfig(testBadOrderArgs{})
+	assert.EqualError(t, err, "cannot build an order by type for sugar.testConnPet: it has no sortable fields")
+}
+
+func TestLoadOrderBy(t *testing.T) {
+	terms, err := LoadOrderBy(testOrderPet{}, []interface{}{
+		map[string]interface{}{"field": "createdAt", "direction": OrderDesc},
+		map[string]interface{}{"field": "name"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []OrderTerm{{Field: "createdAt", Direction: OrderDesc}, {Field: "name", Direction: OrderAsc}}, terms)
+
+	_, err = LoadOrderBy(testOrderPet{}, []interface{}{
+		map[string]interface{}{"field": "tags"},
+		map[string]interface{}{"field": "name", "direction": "SIDEWAYS"},
+	})
+	if assert.IsType(t, ArgErrors{}, err) {
+		errs := err.(ArgErrors)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, "[0].field", errs[0].Path)
+		assert.Equal(t, ErrCodeValidation, errs[0].Code)
+		assert.Equal(t, "[1].direction", errs[1].Path)
+	}
+
+	// the errors are reported under the argument's name by LoadArgs.
+	args := testOrderArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"orderBy": []interface{}{map[string]interface{}{"field": "tags"}},
+	}}, &args)
+	if assert.IsType(t, ArgErrors{}, err) {
+		assert.Equal(t, "orderBy[0].field", err.(ArgErrors)[0].Path)
+	}
+}
+
+func TestOrderTermsArg(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"sorts": &graphql.Field{
+					Type: graphql.NewList(graphql.String),
+					Args: ArgsConfig(testOrderArgs{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testOrderArgs{}
+						if err := LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						out := []string{}
+						for _, term := range args.OrderBy {
+							out = append(out, term.Field+" "+string(term.Direction))
+						}
+						return out, nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ sorts(orderBy: [{field: CREATED_AT, direction: DESC}, {field: NAME}]) }`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{"sorts": []interface{}{"createdAt DESC", "name ASC"}}, result.Data)
+
+	result = graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query($o: [TestOrderPetOrder!]) { sorts(orderBy: $o) }`,
+		VariableValues: map[string]interface{}{"o": []interface{}{map[string]interface{}{"field": "NAME", "direction": "DESC"}}},
+	})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]interface{}{"sorts": []interface{}{"name DESC"}}, result.Data)
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ sorts(orderBy: [{field: TAGS}]) }`,
+	})
+	assert.NotEmpty(t, result.Errors)
+}
+
+func TestEnumValueName(t *testing.T) {
+	tests := map[string]string{
+		"name":      "NAME",
+		"createdAt": "CREATED_AT",
+		"user_id":   "USER_ID",
+		"address2":  "ADDRESS2",
+		"zipCode4":  "ZIP_CODE4",
+		"some-key":  "SOME_KEY",
+	}
+	for in, out := range tests {
+		assert.Equal(t, out, enumValueName(in))
+	}
+}
@@ -2,10 +2,15 @@ package sugar
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/graphql-go/graphql"
 	"github.com/guregu/null"
@@ -14,65 +19,187 @@ import (
 
 // NewTypeBuilder creates a new TypeBuilder and registers known types on it.
 func NewTypeBuilder() *TypeBuilder {
-	tb := TypeBuilder{
-		knownTypes: map[reflect.Type]graphql.Output{},
-	}
+	tb := &TypeBuilder{}
+	tb.knownTypes.Store(&typeMap{})
+	// uploads only go one way, so they're only known as input types.
+	tb.inputTypes.Store(&typeMap{reflect.TypeOf(Upload{}): UploadScalar})
 	tb.RegisterKnownType(time.Now(), Timestamp)
 	tb.RegisterKnownType(sql.NullString{}, graphql.String)
-	tb.RegisterKnownType(null.Int{}, graphql.Int)
+	tb.RegisterKnownType(null.Int{}, Long)
 	tb.RegisterKnownType(null.String{}, graphql.String)
 	tb.RegisterKnownType(pq.NullTime{}, Timestamp)
 	tb.RegisterKnownType(null.Time{}, Timestamp)
+	tb.RegisterKnownType(null.Bool{}, graphql.Boolean)
+	tb.RegisterKnownType(null.Float{}, graphql.Float)
+	tb.RegisterKnownType(sql.NullInt64{}, Long)
+	tb.RegisterKnownType(sql.NullInt32{}, graphql.Int)
+	tb.RegisterKnownType(sql.NullInt16{}, graphql.Int)
+	tb.RegisterKnownType(sql.NullByte{}, graphql.Int)
+	tb.RegisterKnownType(sql.NullFloat64{}, graphql.Float)
+	tb.RegisterKnownType(sql.NullBool{}, graphql.Boolean)
+	tb.RegisterKnownType(sql.NullTime{}, Timestamp)
+	tb.RegisterKnownType(OrderAsc, orderDirectionEnum)
 
-	return &tb
+	return tb
 }
 
-// A TypeBuilder helps create graphql-go output types
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// a typeMap maps Go types to the GraphQL types that represent them.
+type typeMap map[reflect.Type]graphql.Output
+
+// A TypeBuilder helps create graphql-go output types.  It is safe for concurrent use.
 type TypeBuilder struct {
-	knownTypes map[reflect.Type]graphql.Output
+	// the types registered or built so far.  Readers load it without locking.  Writers hold mu, and
+	// replace it with an updated copy.
+	knownTypes atomic.Pointer[typeMap]
+	mu         sync.Mutex
+
+	// input types built by InputType, kept separately since a struct is described by both an
+	// object and an input object.  It's updated like knownTypes.
+	inputTypes atomic.Pointer[typeMap]
+
+	// while OutputType or InputType builds a type, the types it creates are collected here and
+	// published together when it's done.  Guarded by mu.
+	building       typeMap
+	buildingInputs typeMap
+
+	// namedEntries for the types built by helpers like Connection, which aren't tied to one Go
+	// type, by their GraphQL names.  namedMu is held while building them.
+	namedTypes sync.Map
+	namedMu    sync.Mutex
+
+	frozen bool
 }
 
 // RegisterKnownType takes any value, and the GraphQL type that should represent it, and will use that when building types.
+// It panics if the type can't be registered.
 func (tb *TypeBuilder) RegisterKnownType(val interface{}, gqlType graphql.Output) {
-	name := gqlType.Name()
-	// panic if asked to create a graphql type with a lowercase first character.
-	if string(name[0]) == strings.ToLower(string(name[0])) {
-		panic(fmt.Sprintf("refusing to build GraphQL type with lowercase name %s. val: %+v", name, val))
+	if err := tb.SafeRegisterKnownType(val, gqlType); err != nil {
+		panic(err)
 	}
-	tb.knownTypes[getType(val)] = gqlType
+}
+
+// SafeRegisterKnownType is like RegisterKnownType, but returns an error instead of panicking,
+// including when the TypeBuilder has been frozen.
+func (tb *TypeBuilder) SafeRegisterKnownType(val interface{}, gqlType graphql.Output) error {
+	if err := checkTypeName(gqlType, val); err != nil {
+		return err
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if tb.frozen {
+		return ErrFrozen
+	}
+	types := tb.knownTypes.Load().clone()
+	types[getType(val)] = gqlType
+	tb.knownTypes.Store(&types)
+	return nil
+}
+
+// Freeze makes any further call to SafeRegisterKnownType or RegisterEnum on the TypeBuilder return
+// ErrFrozen (and RegisterKnownType panic).  OutputType keeps working, and still remembers the types
+// it builds.
+func (tb *TypeBuilder) Freeze() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.frozen = true
+}
+
+// isFrozen reports whether Freeze has been called on the TypeBuilder.
+func (tb *TypeBuilder) isFrozen() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.frozen
 }
 
 // OutputType builds a GraphQL type for you from the given name, desc, and val.  val may be a
 // struct instance, slice, array, pointer, or an alias to a builtin type like int or string.
+// int64, uint, uint32, and uint64 are described as Long, since GraphQL's Int only holds 32 bits, and
+// so are the null.Int and sql.NullInt64 wrappers.
 func (tb *TypeBuilder) OutputType(name, desc string, val interface{}) graphql.Output {
+	// types that have been built before can be returned without locking.
+	if knownType, ok := (*tb.knownTypes.Load())[getType(val)]; ok {
+		return knownType
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.building = tb.knownTypes.Load().clone()
+	defer func() { tb.building = nil }()
+	t := tb.outputType(name, desc, val)
+	types := tb.building
+	tb.knownTypes.Store(&types)
+	return t
+}
+
+// a namedKey says what a type built by namedType describes, like "a filter of" a Go type, so that
+// a name can't be reused for something else.  of must be comparable.
+type namedKey struct {
+	kind string
+	of   interface{}
+}
+
+func (k namedKey) String() string {
+	if k.of == nil {
+		return k.kind
+	}
+	return fmt.Sprintf("%s %v", k.kind, k.of)
+}
+
+// a namedEntry is a type built by namedType, and what it describes.
+type namedEntry struct {
+	key namedKey
+	t   graphql.Output
+}
+
+// namedType returns the type already built with the given name, or builds it.  It returns an error
+// if the name was used for a type built from a different key.  build must not call namedType.
+func (tb *TypeBuilder) namedType(name string, key namedKey, build func() graphql.Output) (graphql.Output, error) {
+	if entry, ok := tb.namedTypes.Load(name); ok {
+		return entry.(namedEntry).check(name, key)
+	}
+	tb.namedMu.Lock()
+	defer tb.namedMu.Unlock()
+	if entry, ok := tb.namedTypes.Load(name); ok {
+		return entry.(namedEntry).check(name, key)
+	}
+	t := build()
+	tb.namedTypes.Store(name, namedEntry{key: key, t: t})
+	return t, nil
+}
+
+// check returns the entry's type if it was built from key.
+func (entry namedEntry) check(name string, key namedKey) (graphql.Output, error) {
+	if entry.key != key {
+		return nil, fmt.Errorf("the GraphQL type name %s is already used for %v", name, entry.key)
+	}
+	return entry.t, nil
+}
+
+// outputType does the work of OutputType.  The caller must hold mu, and have set up tb.building.
+func (tb *TypeBuilder) outputType(name, desc string, val interface{}) graphql.Output {
 	// obj can be a reflect.type, or a concrete value
 	objType := getType(val)
 
 	// check known types first, so we don't recurse into time.Time structs, for example.
-	if knownType, ok := tb.knownTypes[objType]; ok {
+	if knownType, ok := tb.building[objType]; ok {
 		return knownType
 	}
 
-	switch kind := objType.Kind(); kind {
-	case reflect.Bool:
-		tb.RegisterKnownType(objType, graphql.Boolean)
-		return graphql.Boolean
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		tb.RegisterKnownType(objType, graphql.Int)
-		return graphql.Int
-	case reflect.Float32, reflect.Float64:
-		tb.RegisterKnownType(objType, graphql.Float)
-		return graphql.Float
-	case reflect.String:
-		tb.RegisterKnownType(objType, graphql.String)
-		return graphql.String
+	if scalar, ok := kindScalar(objType.Kind()); ok {
+		tb.remember(objType, scalar)
+		return scalar
+	}
+
+	switch objType.Kind() {
 	case reflect.Ptr:
-		t := tb.OutputType(name, desc, objType.Elem())
-		tb.RegisterKnownType(objType, t)
+		t := tb.outputType(name, desc, objType.Elem())
+		tb.remember(objType, t)
 		return t
 	case reflect.Slice, reflect.Array:
-		t := graphql.NewList(tb.OutputType(name, desc, objType.Elem()))
-		tb.RegisterKnownType(objType, t)
+		t := graphql.NewList(tb.outputType(name, desc, objType.Elem()))
+		tb.remember(objType, t)
 		return t
 	case reflect.Struct:
 		obj := graphql.NewObject(graphql.ObjectConfig{
@@ -81,7 +208,7 @@ func (tb *TypeBuilder) OutputType(name, desc string, val interface{}) graphql.Ou
 			Fields:      tb.structFieldMap(objType, false),
 		})
 		// remember this type in our map to keep things consistent and fast.
-		tb.RegisterKnownType(objType, obj)
+		tb.remember(objType, obj)
 		return obj
 	default:
 		fmt.Println("type", objType, "name", name)
@@ -89,6 +216,52 @@ func (tb *TypeBuilder) OutputType(name, desc string, val interface{}) graphql.Ou
 	}
 }
 
+// kindScalar returns the GraphQL scalar for Go values of the given kind, if there is one.
+func kindScalar(kind reflect.Kind) (*graphql.Scalar, bool) {
+	switch kind {
+	case reflect.Bool:
+		return graphql.Boolean, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return graphql.Int, true
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		// these don't fit in GraphQL's 32-bit Int, which graphql-go serializes as null when a value
+		// is out of range.
+		return Long, true
+	case reflect.Float32, reflect.Float64:
+		return graphql.Float, true
+	case reflect.String:
+		return graphql.String, true
+	}
+	return nil, false
+}
+
+// remember adds a type built by outputType to tb.building.  The caller must hold mu.
+func (tb *TypeBuilder) remember(t reflect.Type, gqlType graphql.Output) {
+	if err := checkTypeName(gqlType, t); err != nil {
+		panic(err)
+	}
+	tb.building[t] = gqlType
+}
+
+// checkTypeName refuses to build a GraphQL type with a lowercase first character.  Wrapping types
+// like lists are named after their contents, as in "[String]", and are let through.
+func checkTypeName(gqlType graphql.Output, val interface{}) error {
+	name := gqlType.Name()
+	if r, _ := utf8.DecodeRuneInString(name); unicode.IsLower(r) {
+		return fmt.Errorf("refusing to build GraphQL type with lowercase name %s. val: %+v", name, val)
+	}
+	return nil
+}
+
+// clone returns a copy of the map that can be modified.
+func (m *typeMap) clone() typeMap {
+	out := make(typeMap, len(*m))
+	for t, gqlType := range *m {
+		out[t] = gqlType
+	}
+	return out
+}
+
 // Union is just like OutputType, but takes in multiple vals and builds a GraphQL union type out of
 // them.
 func (tb *TypeBuilder) Union(name, desc string, vals ...interface{}) *graphql.Union {
@@ -100,7 +273,7 @@ func (tb *TypeBuilder) Union(name, desc string, vals ...interface{}) *graphql.Un
 
 	for _, v := range vals {
 		objType := getType(v)
-		if gqlType, ok := tb.knownTypes[objType]; ok {
+		if gqlType, ok := (*tb.knownTypes.Load())[objType]; ok {
 			gqlObj := gqlType.(*graphql.Object)
 			typeMap[objType] = gqlObj
 			typeList = append(typeList, gqlObj)
@@ -123,6 +296,8 @@ func (tb *TypeBuilder) Union(name, desc string, vals ...interface{}) *graphql.Un
 	})
 }
 
+// structFieldMap builds the fields of a GraphQL object from the json-tagged fields of a struct.  The
+// caller must hold mu.
 func (tb *TypeBuilder) structFieldMap(val interface{}, embedded bool) graphql.Fields {
 	structType := getType(val)
 	fieldMap := graphql.Fields{}
@@ -148,7 +323,7 @@ func (tb *TypeBuilder) structFieldMap(val interface{}, embedded bool) graphql.Fi
 			continue
 		default:
 			gqlField := &graphql.Field{
-				Type:        tb.OutputType(jsonName, field.Tag.Get("desc"), field.Type),
+				Type:        tb.outputType(jsonName, field.Tag.Get("desc"), field.Type),
 				Description: field.Tag.Get("desc"),
 			}
 
@@ -157,11 +332,17 @@ func (tb *TypeBuilder) structFieldMap(val interface{}, embedded bool) graphql.Fi
 				gqlField.DeprecationReason = deprecation
 			}
 
-			// if we're in an embedded struct, then we need to add a resolver
-			if embedded {
+			// if we're in an embedded struct, then we need to add a resolver.  Nullable wrappers
+			// like null.Int also need one, to unwrap them into something the scalars can serialize.
+			valuer := nullWrapper(field.Type)
+			if embedded || valuer {
 				gqlField.Resolve = func(p graphql.ResolveParams) (interface{}, error) {
 					val := reflect.ValueOf(p.Source)
-					return reflect.Indirect(val).FieldByName(field.Name).Interface(), nil
+					out := reflect.Indirect(val).FieldByName(field.Name).Interface()
+					if valuer {
+						return out.(driver.Valuer).Value()
+					}
+					return out, nil
 				}
 			}
 			fieldMap[jsonName] = gqlField
@@ -178,3 +359,82 @@ func getType(val interface{}) reflect.Type {
 		return reflect.TypeOf(val)
 	}
 }
+
+// a jsonField is a struct field with a json tag, which may be promoted from an embedded struct.
+type jsonField struct {
+	name  string
+	index []int
+	field reflect.StructField
+}
+
+// jsonFields returns the json-tagged fields of a struct type, flattening untagged embedded structs
+// like encoding/json does.  Fields of the parent win over promoted fields with the same name.
+func jsonFields(t reflect.Type) []jsonField {
+	return embeddedJSONFields(t, nil)
+}
+
+// embeddedJSONFields returns the json-tagged fields of t, which is embedded in the structs in outer.
+// An embedding cycle is skipped, like compileEmbedding does.
+func embeddedJSONFields(t reflect.Type, outer []reflect.Type) []jsonField {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := []jsonField{}
+	promoted := []jsonField{}
+	taken := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" {
+			if !field.IsExported() && field.Type.Kind() == reflect.Ptr {
+				// encoding/json skips these too, since their fields can't be set through reflection.
+				continue
+			}
+			embedded := derefType(field.Type)
+			if embeddingCycle(embedded, t, outer) {
+				continue
+			}
+			for _, f := range embeddedJSONFields(embedded, append(outer[:len(outer):len(outer)], t)) {
+				f.index = append([]int{i}, f.index...)
+				promoted = append(promoted, f)
+			}
+			continue
+		}
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		taken[name] = true
+		fields = append(fields, jsonField{name: name, index: []int{i}, field: field})
+	}
+	for _, f := range promoted {
+		if !taken[f.name] {
+			taken[f.name] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// jsonFieldMaps caches jsonFieldMap's results by type.
+var jsonFieldMaps sync.Map
+
+// jsonFieldMap returns the json-tagged fields of a struct type by name.
+func jsonFieldMap(t reflect.Type) map[string]jsonField {
+	if m, ok := jsonFieldMaps.Load(t); ok {
+		return m.(map[string]jsonField)
+	}
+	m := map[string]jsonField{}
+	for _, f := range jsonFields(t) {
+		m[f.name] = f
+	}
+	jsonFieldMaps.Store(t, m)
+	return m
+}
+
+// derefType returns the type that t points to, following any number of pointers.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,489 @@
+package sugar
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/btubbs/pqjson"
+	"github.com/guregu/null"
+)
+
+// A structPlan is the compiled description of how to load an arg struct type, so that struct tags
+// are only read and loaders only looked up once per type.
+type structPlan struct {
+	fields []*fieldPlan
+	// why the struct type can't be used for arguments, if it can't.
+	err error
+}
+
+// A fieldPlan describes how to load one tagged struct field.
+type fieldPlan struct {
+	// the index sequence for reflect's FieldByIndex, which is longer than one for fields promoted
+	// from embedded structs.
+	index      []int
+	field      reflect.StructField
+	name       string
+	desc       string
+	config     TagOptions
+	required   bool
+	hasDefault bool
+	// whether the field can hold an explicit null.
+	nullable   bool
+	load       valueLoader
+	validators []fieldValidator
+
+	// the default from the tag is parsed on first use, since that may require building input
+	// objects.
+	defaultOnce  sync.Once
+	defaultValue interface{}
+	defaultErr   error
+}
+
+// a valueLoader converts an incoming argument value to a Go value.  Problems with the value are
+// appended to valErrs and reported by returning an invalid reflect.Value.  A returned error means the
+// value cannot be loaded at all.
+type valueLoader func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error)
+
+// plan returns the cached structPlan for t, compiling it if needed, or the reason t can't be used
+// for arguments.  It's safe for concurrent use.
+func (e *ArgLoader) plan(t reflect.Type) (*structPlan, error) {
+	plans := e.reg.Load().plans
+	if p, ok := plans.Load(t); ok {
+		return p.(*structPlan), p.(*structPlan).err
+	}
+	p, _ := plans.LoadOrStore(t, e.compilePlan(t))
+	return p.(*structPlan), p.(*structPlan).err
+}
+
+// filterLoader returns the cached valueLoader for the values of filter conditions on fields of type
+// t, compiling it if needed.
+func (e *ArgLoader) filterLoader(t reflect.Type) valueLoader {
+	loaders := e.reg.Load().filterLoaders
+	if l, ok := loaders.Load(t); ok {
+		return l.(valueLoader)
+	}
+	l, _ := loaders.LoadOrStore(t, e.compileLoader(t, nil))
+	return l.(valueLoader)
+}
+
+// defaultSyntax reads tags with the default syntax, for PageArgs.
+var defaultSyntax = &ArgLoader{
+	tag:        defaultTag,
+	descTag:    defaultDescTag,
+	separator:  defaultSeparator,
+	assignor:   defaultAssignor,
+	nameMapper: func(fieldName string) string { return fieldName },
+}
+
+func (e *ArgLoader) compilePlan(t reflect.Type) *structPlan {
+	return e.compileEmbedding(t, nil)
+}
+
+// compileEmbedding compiles the plan for t, which is embedded in the structs in outer.  Embedded
+// structs are compiled with the path that led to them, rather than from the plan cache, so that an
+// embedding cycle can be skipped like encoding/json does.
+func (e *ArgLoader) compileEmbedding(t reflect.Type, outer []reflect.Type) *structPlan {
+	// PageArgs is tagged with the default syntax, and works with ArgLoaders that use another.
+	syntax := e
+	if t == typeOf[PageArgs]() {
+		syntax = defaultSyntax
+	}
+	p := &structPlan{}
+	taken := map[string]bool{}
+	promoted := []*fieldPlan{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous && !field.IsExported() {
+			// unexported fields can't be set, even if they're tagged.  jsonFields skips them too.
+			continue
+		}
+		if field.Anonymous && !field.IsExported() && field.Type.Kind() == reflect.Ptr {
+			// the fields of an embedded pointer to an unexported struct can't be set through
+			// reflection, since the pointer can't be allocated.  Skip it, like encoding/json does.
+			continue
+		}
+		if embedded, ok := e.embeddedStruct(field); ok {
+			// an untagged embedded struct is flattened.  Its fields are added after ours, so that
+			// fields on the parent win when names collide.
+			if embeddingCycle(embedded, t, outer) {
+				continue
+			}
+			embeddedPlan := e.compileEmbedding(embedded, append(outer[:len(outer):len(outer)], t))
+			if embeddedPlan.err != nil {
+				return embeddedPlan
+			}
+			for _, fp := range embeddedPlan.fields {
+				promoted = append(promoted, fp.promote(i))
+			}
+			continue
+		}
+		argName, config, ok := syntax.readTag(field)
+		if !ok {
+			// this field doesn't have our tag.  Skip.
+			continue
+		}
+		validators, err := e.compileValidators(config)
+		if err != nil {
+			return &structPlan{err: fmt.Errorf("%s %v", argName, err)}
+		}
+		taken[argName] = true
+		p.fields = append(p.fields, &fieldPlan{
+			index:      []int{i},
+			field:      field,
+			name:       argName,
+			desc:       field.Tag.Get(syntax.descTag),
+			config:     config,
+			required:   config.Has(tagKeyRequired),
+			hasDefault: config.Has(tagKeyDefault),
+			nullable:   e.nullable(field.Type),
+			load:       e.compileLoader(field.Type, config),
+			validators: validators,
+		})
+	}
+	for _, fp := range promoted {
+		if !taken[fp.name] {
+			taken[fp.name] = true
+			p.fields = append(p.fields, fp)
+		}
+	}
+	return p
+}
+
+// nullable reports whether a field of type t can tell an explicit null from a missing argument.
+// That's true of Optionals, and of wrapper types like sql.NullString and null.Int that have a
+// registered loader, which is given nil for a null.
+func (e *ArgLoader) nullable(t reflect.Type) bool {
+	if _, ok := optionalElem(t); ok {
+		return true
+	}
+	_, registered := e.reg.Load().loaderFuncs[t]
+	return registered && nullWrapper(t)
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// nullWrapper reports whether t is a nullable wrapper type like sql.NullString or null.Int, which
+// implement driver.Valuer and sql.Scanner.
+func nullWrapper(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.Implements(valuerType) && reflect.PtrTo(t).Implements(scannerType)
+}
+
+// embeddingCycle reports whether embedded is t or one of the structs that t is embedded in.
+func embeddingCycle(embedded, t reflect.Type, outer []reflect.Type) bool {
+	if embedded == t {
+		return true
+	}
+	for _, o := range outer {
+		if embedded == o {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedStruct returns the struct type of an embedded struct or struct pointer field without an
+// arg tag.  An embedded struct with an arg tag is loaded like any other struct field.
+func (e *ArgLoader) embeddedStruct(field reflect.StructField) (reflect.Type, bool) {
+	if !field.Anonymous {
+		return nil, false
+	}
+	if _, tagged := field.Tag.Lookup(e.tag); tagged {
+		return nil, false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct
+}
+
+// promote returns a copy of fp for a struct that embeds fp's struct as its field at index i.
+func (fp *fieldPlan) promote(i int) *fieldPlan {
+	return &fieldPlan{
+		index:      append([]int{i}, fp.index...),
+		field:      fp.field,
+		name:       fp.name,
+		desc:       fp.desc,
+		config:     fp.config,
+		required:   fp.required,
+		hasDefault: fp.hasDefault,
+		nullable:   fp.nullable,
+		load:       fp.load,
+		validators: fp.validators,
+	}
+}
+
+// settableField returns the field of struct v at the given index sequence, allocating any nil
+// embedded struct pointers along the way.
+func settableField(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// tagDefault returns the parsed default from the field's tag.
+func (fp *fieldPlan) tagDefault(e *ArgLoader) (interface{}, error) {
+	fp.defaultOnce.Do(func() {
+		e.typesMu.Lock()
+		defer e.typesMu.Unlock()
+		argType, err := e.argType(fp.field.Type, fp.name)
+		if err != nil {
+			fp.defaultErr = err
+			return
+		}
+		fp.defaultValue, fp.defaultErr = e.parseDefault(fp.config[tagKeyDefault], fp.field.Type, argType, fp.config)
+	})
+	return fp.defaultValue, fp.defaultErr
+}
+
+// validate runs the field's validators against the loaded value v, appending any failures to
+// valErrs.
+func (fp *fieldPlan) validate(v reflect.Value, path string, e *ArgLoader, valErrs *ArgErrors) {
+	// Optionals are validated by their value, when they have one.
+	v, ok := optionalValue(v)
+	if !ok {
+		return
+	}
+	for _, fv := range fp.validators {
+		if err := fv.check(v.Interface()); err != nil {
+			valErrs.add(newArgError(ErrCodeValidation, path, v.Type(), e.expectedType(v.Type()), err))
+		}
+	}
+}
+
+// compileLoader returns a valueLoader for type t.  Registered loaders are used when there is one
+// for t, followed by ArgUnmarshaler and encoding.TextUnmarshaler implementations.  Otherwise
+// Optionals, pointers, slices, arrays, and structs are loaded from their parts.
+func (e *ArgLoader) compileLoader(t reflect.Type, config TagOptions) valueLoader {
+	if loaderFunc, ok := e.reg.Load().loaderFuncs[t]; ok {
+		coalesceZero := config.Has(tagKeyCoalesceZero)
+		return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+			toSet, err := loaderFunc(i, config)
+			if err != nil {
+				if nested, ok := err.(ArgErrors); ok && !coalesceZero {
+					// the loader found problems inside the value, which are reported by their own
+					// paths.
+					valErrs.addNested(path, nested)
+					return reflect.Value{}, nil
+				}
+				if !coalesceZero {
+					valErrs.add(newArgError(ErrCodeInvalidType, path, t, e.expectedType(t), err))
+					return reflect.Value{}, nil
+				}
+				toSet = reflect.Zero(t)
+			}
+			return toSet, nil
+		}
+	}
+
+	if unmarshal, ok := e.unmarshalerLoader(t); ok {
+		return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+			toSet, err := unmarshal(i)
+			if nested, ok := err.(ArgErrors); ok {
+				valErrs.addNested(path, nested)
+				return reflect.Value{}, nil
+			}
+			if err != nil {
+				valErrs.add(newArgError(ErrCodeInvalidType, path, t, e.expectedType(t), err))
+				return reflect.Value{}, nil
+			}
+			return toSet, nil
+		}
+	}
+
+	if elem, ok := optionalElem(t); ok {
+		return loadOptional(t, e.compileLoader(elem, config))
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		loadElem := e.compileLoader(t.Elem(), config)
+		return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+			if i == nil {
+				return reflect.Zero(t), nil
+			}
+			elem, err := loadElem(i, path, valErrs)
+			if err != nil || !elem.IsValid() {
+				return reflect.Value{}, err
+			}
+			ptr := reflect.New(t.Elem())
+			ptr.Elem().Set(elem)
+			return ptr, nil
+		}
+	case reflect.Slice, reflect.Array:
+		loadElem := e.compileLoader(t.Elem(), config)
+		return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+			return e.loadList(i, t, loadElem, path, valErrs)
+		}
+	case reflect.Struct:
+		// the struct's own plan is looked up when loading, so that self-referencing types don't
+		// compile forever.
+		return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+			m, ok := i.(map[string]interface{})
+			if !ok {
+				valErrs.add(newArgError(ErrCodeInvalidType, path, t, e.expectedType(t), fmt.Errorf("%v is not an object", i)))
+				return reflect.Value{}, nil
+			}
+			errCount := len(*valErrs)
+			v := reflect.New(t).Elem()
+			if err := e.loadStruct(m, v, path+".", valErrs); err != nil {
+				return reflect.Value{}, err
+			}
+			if len(*valErrs) > errCount {
+				return reflect.Value{}, nil
+			}
+			return v, nil
+		}
+	}
+	err := fmt.Errorf("no loader function found for type %v", t)
+	return func(i interface{}, path string, valErrs *ArgErrors) (reflect.Value, error) {
+		return reflect.Value{}, err
+	}
+}
+
+// loadList converts i to a slice or array of type t, loading each element with loadElem.
+func (e *ArgLoader) loadList(i interface{}, t reflect.Type, loadElem valueLoader, path string, valErrs *ArgErrors) (reflect.Value, error) {
+	if i == nil {
+		return reflect.Zero(t), nil
+	}
+	in := reflect.ValueOf(i)
+	if in.Kind() != reflect.Slice && in.Kind() != reflect.Array {
+		valErrs.add(newArgError(ErrCodeInvalidType, path, t, e.expectedType(t), fmt.Errorf("%v is not a list", i)))
+		return reflect.Value{}, nil
+	}
+
+	var out reflect.Value
+	if t.Kind() == reflect.Array {
+		if in.Len() > t.Len() {
+			valErrs.add(newArgError(ErrCodeValidation, path, t, e.expectedType(t), fmt.Errorf("has more than %d items", t.Len())))
+			return reflect.Value{}, nil
+		}
+		out = reflect.New(t).Elem()
+	} else {
+		out = reflect.MakeSlice(t, in.Len(), in.Len())
+	}
+
+	errCount := len(*valErrs)
+	for idx := 0; idx < in.Len(); idx++ {
+		elem, err := loadElem(in.Index(idx).Interface(), fmt.Sprintf("%s[%d]", path, idx), valErrs)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if elem.IsValid() {
+			out.Index(idx).Set(elem)
+		}
+	}
+	if len(*valErrs) > errCount {
+		return reflect.Value{}, nil
+	}
+	return out, nil
+}
+
+// directLoader wraps the loader func types that ship with this package without going through
+// reflect.Value.Call.  ok is false for other func types.
+func directLoader(f interface{}, fname string) (loader loaderFunc, ok bool) {
+	switch f := f.(type) {
+	case func(interface{}) (bool, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (*bool, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (string, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}, TagOptions) (string, error):
+		return wrapLoader(f, fname), true
+	case func(interface{}) (int, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (int8, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (int16, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (int32, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (int64, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (uint, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (uint8, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (uint16, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (uint32, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (uint64, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (float32, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (float64, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (time.Time, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}, TagOptions) (time.Time, error):
+		return wrapLoader(f, fname), true
+	case func(interface{}) (null.Int, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (null.String, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (null.Bool, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (null.Float, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (null.Time, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullString, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullInt64, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullInt32, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullInt16, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullByte, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullFloat64, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullBool, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (sql.NullTime, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (json.RawMessage, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	case func(interface{}) (pqjson.RawMessage, error):
+		return wrapLoader(withoutOptions(f), fname), true
+	}
+	return nil, false
+}
+
+// withoutOptions adapts a loader func that doesn't care about tag options.
+func withoutOptions[T any](f func(any) (T, error)) func(any, TagOptions) (T, error) {
+	return func(i any, _ TagOptions) (T, error) { return f(i) }
+}
+
+// wrapLoader turns a typed loader func into the form stored on an ArgLoader.  fname identifies f in
+// error messages.
+func wrapLoader[T any](f func(any, TagOptions) (T, error), fname string) loaderFunc {
+	return func(i interface{}, config TagOptions) (v reflect.Value, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				// we panicked running the inner loader func.
+				err = fmt.Errorf("%s panicked: %s", fname, p)
+			}
+		}()
+		out, err := f(i, config)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		// go through a pointer so that interface types keep their static type.
+		return reflect.ValueOf(&out).Elem(), nil
+	}
+}
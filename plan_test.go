@@ -0,0 +1,159 @@
+package sugar
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLengthArg int
+
+type testPlanArgs struct {
+	ID     string        `arg:"id,required"`
+	Name   string        `arg:"name,min:1,max:100"`
+	Limit  int           `arg:"limit,default:20"`
+	Tags   []string      `arg:"tags"`
+	Length testLengthArg `arg:"length"`
+}
+
+func loadTestLength(i interface{}) (testLengthArg, error) {
+	n, ok := i.(int)
+	if !ok {
+		return 0, errors.New("not an int")
+	}
+	return testLengthArg(n), nil
+}
+
+var testPlanParams = graphql.ResolveParams{Args: map[string]interface{}{
+	"id":     "bob",
+	"name":   "Bob Loblaw",
+	"tags":   []interface{}{"a", "b", "c"},
+	"length": 12,
+}}
+
+func TestPlanReset(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+
+	// without a loader for testLengthArg, the plan can't load that field.
+	err = loader.LoadArgs(testPlanParams, &testPlanArgs{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "no loader function found")
+	}
+
+	// registering one throws away the stale plan.
+	assert.Nil(t, loader.RegisterArgParser(loadTestLength, graphql.Int))
+	args := testPlanArgs{}
+	assert.Nil(t, loader.LoadArgs(testPlanParams, &args))
+	assert.Equal(t, testPlanArgs{ID: "bob", Name: "Bob Loblaw", Limit: 20, Tags: []string{"a", "b", "c"}, Length: 12}, args)
+}
+
+func TestPlanConcurrentLoad(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	assert.Nil(t, loader.RegisterArgParser(loadTestLength, graphql.Int))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			args := testPlanArgs{}
+			assert.Nil(t, loader.LoadArgs(testPlanParams, &args))
+			assert.Equal(t, "bob", args.ID)
+		}()
+	}
+	wg.Wait()
+}
+
+func benchmarkLoader(b *testing.B) *ArgLoader {
+	loader, err := New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	// testLengthArg goes through reflect.Value.Call, like any user loader registered with
+	// RegisterArgParser.
+	if err := loader.RegisterArgParser(loadTestLength, graphql.Int); err != nil {
+		b.Fatal(err)
+	}
+	return loader
+}
+
+// BenchmarkLoadArgs loads args with a warm plan cache.
+func BenchmarkLoadArgs(b *testing.B) {
+	loader := benchmarkLoader(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		args := testPlanArgs{}
+		if err := loader.LoadArgs(testPlanParams, &args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadArgsUncached compiles a fresh plan for every load, so each one walks the struct
+// fields, reads their tags, and parses validator parameters, as LoadArgs did before plans were
+// cached.
+func BenchmarkLoadArgsUncached(b *testing.B) {
+	loader := benchmarkLoader(b)
+	t := typeOf[testPlanArgs]()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := loader.compilePlan(t)
+		args := testPlanArgs{}
+		valErrs := ArgErrors{}
+		if err := loader.loadPlan(p, testPlanParams.Args, reflect.ValueOf(&args).Elem(), "", &valErrs); err != nil || len(valErrs) > 0 {
+			b.Fatal(err, valErrs)
+		}
+	}
+}
+
+// BenchmarkLoaderReflectCall and BenchmarkLoaderDirect compare a loader called through
+// reflect.Value.Call with the same loader wrapped directly.
+func BenchmarkLoaderReflectCall(b *testing.B) {
+	loader := benchmarkLoader(b)
+	f := loader.reg.Load().loaderFuncs[typeOf[testLengthArg]()]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f(12, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoaderDirect(b *testing.B) {
+	f := wrapLoader(withoutOptions(loadTestLength), "loadTestLength")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f(12, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type testBadValidatorArgs struct {
+	Name string `arg:"name,pattern:a(b"`
+	Age  int    `arg:"age,min:ten"`
+}
+
+func TestPlanBadValidatorParams(t *testing.T) {
+	_, err := SafeArgsConfig(testBadValidatorArgs{})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `name has an invalid pattern "a(b"`, err.Error())
+	}
+
+	// LoadArgs fails outright instead of reporting a validation error.
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"name": "ab"}}, &testBadValidatorArgs{})
+	if assert.NotNil(t, err) {
+		_, isArgErrors := err.(ArgErrors)
+		assert.False(t, isArgErrors)
+	}
+}
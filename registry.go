@@ -0,0 +1,137 @@
+package sugar
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ErrFrozen is returned when registering something on an ArgLoader or TypeBuilder after Freeze has
+// been called on it.
+var ErrFrozen = errors.New("cannot register after Freeze")
+
+// a loaderFunc takes an argument value and the tag options of the field being loaded, and returns a
+// reflect value of the type it was registered for.
+type loaderFunc func(interface{}, TagOptions) (reflect.Value, error)
+
+// argRegistry holds what has been registered on an ArgLoader.  It's never modified once an
+// ArgLoader has published it; registering publishes an updated copy instead.
+type argRegistry struct {
+	// a map from reflect types to functions that can take an interface and return a
+	// reflect value of that type.
+	loaderFuncs map[reflect.Type]loaderFunc
+
+	// a map from reflect types to the graphql types that should be used for their arguments.
+	gqlTypes map[reflect.Type]graphql.Input
+
+	// validators that can be named as options in the arg tag.
+	validators map[string]validatorCompiler
+
+	// compiled structPlans for each arg struct type.  They refer to the loaders and validators
+	// above, so each copy of the registry starts with none.
+	plans *sync.Map
+
+	// input objects generated for struct-typed fields, so that each Go struct is only described to
+	// GraphQL once.  Their field types come from the registry, so each copy starts with none too,
+	// and fills in the ArgLoader's namedInputs again when they're next used.
+	inputObjects *sync.Map
+
+	// valueLoaders compiled for the fields of filters, by type.  Like plans, each copy starts with
+	// none.
+	filterLoaders *sync.Map
+}
+
+// clone returns a copy of the registry that can be modified, with empty caches.
+func (r *argRegistry) clone() *argRegistry {
+	out := &argRegistry{
+		loaderFuncs:   make(map[reflect.Type]loaderFunc, len(r.loaderFuncs)),
+		gqlTypes:      make(map[reflect.Type]graphql.Input, len(r.gqlTypes)),
+		validators:    make(map[string]validatorCompiler, len(r.validators)),
+		plans:         &sync.Map{},
+		inputObjects:  &sync.Map{},
+		filterLoaders: &sync.Map{},
+	}
+	for t, f := range r.loaderFuncs {
+		out.loaderFuncs[t] = f
+	}
+	for t, gqlType := range r.gqlTypes {
+		out.gqlTypes[t] = gqlType
+	}
+	for name, f := range r.validators {
+		out.validators[name] = f
+	}
+	return out
+}
+
+// update applies f to a copy of the registry and publishes the result, unless the ArgLoader is
+// frozen or f returns an error.
+func (e *ArgLoader) update(f func(reg *argRegistry) error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.frozen {
+		return ErrFrozen
+	}
+	reg := e.reg.Load().clone()
+	if err := f(reg); err != nil {
+		return err
+	}
+	e.reg.Store(reg)
+	return nil
+}
+
+// Freeze makes any further attempt to register loaders, enums, or validators on the ArgLoader
+// return ErrFrozen.  Call it once the schema is built.  Loading arguments is unaffected.
+func (e *ArgLoader) Freeze() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.frozen = true
+}
+
+// reset publishes a copy of the registry, which throws away the plans built from the old one, and
+// has the input objects filled in again.  It's called when a setting they depend on changes.
+func (e *ArgLoader) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reg.Store(e.reg.Load().clone())
+}
+
+// isFrozen reports whether Freeze has been called on the ArgLoader.
+func (e *ArgLoader) isFrozen() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.frozen
+}
+
+// Clone returns a new ArgLoader with the same loaders, validators, and settings as e.  Registering
+// on either one afterward doesn't affect the other, and the clone isn't frozen even if e is.
+func (e *ArgLoader) Clone() *ArgLoader {
+	clone := &ArgLoader{
+		tag:         e.tag,
+		descTag:     e.descTag,
+		separator:   e.separator,
+		assignor:    e.assignor,
+		nameMapper:  e.nameMapper,
+		typeBuilder: e.typeBuilder,
+	}
+	clone.reg.Store(e.reg.Load().clone())
+	clone.allowNullRequired.Store(e.allowNullRequired.Load())
+	return clone
+}
+
+// Merge registers the loaders and validators of other on e.  Where both have one for the same type
+// or name, other's replaces e's.
+func (e *ArgLoader) Merge(other *ArgLoader) error {
+	src := other.reg.Load()
+	return e.update(func(reg *argRegistry) error {
+		for t, f := range src.loaderFuncs {
+			reg.loaderFuncs[t] = f
+			reg.gqlTypes[t] = src.gqlTypes[t]
+		}
+		for name, f := range src.validators {
+			reg.validators[name] = f
+		}
+		return nil
+	})
+}
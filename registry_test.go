@@ -0,0 +1,188 @@
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgLoaderFreeze(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	loader.Freeze()
+
+	assert.Equal(t, ErrFrozen, loader.RegisterArgParser(loadTestLength, graphql.Int))
+	assert.Equal(t, ErrFrozen, loader.RegisterValidator("slug", ValidateNonEmpty))
+	assert.Equal(t, ErrFrozen, loader.RegisterEnum(testStatus(""), map[string]testStatus{"OPEN": "open"}))
+
+	// loading still works.
+	args := testRequiredArgs{}
+	assert.Nil(t, loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{"id": "bob"}}, &args))
+	assert.Equal(t, "bob", args.ID)
+}
+
+func TestTypeBuilderFreeze(t *testing.T) {
+	tb := NewTypeBuilder()
+	tb.Freeze()
+	assert.Equal(t, ErrFrozen, tb.SafeRegisterKnownType(testLengthArg(0), graphql.Int))
+	assert.Panics(t, func() { tb.RegisterKnownType(testLengthArg(0), graphql.Int) })
+
+	// building types still works.
+	assert.Equal(t, graphql.Int, tb.OutputType("Length", "", testLengthArg(0)))
+}
+
+type testConcurrentOutput struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Count int      `json:"count"`
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	tb := NewTypeBuilder()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Nil(t, loader.RegisterValidator(fmt.Sprintf("v%d", i), ValidateNonEmpty))
+			_, err := loader.SafeArgsConfig(testNestedArgs{})
+			assert.Nil(t, err)
+			args := testNestedArgs{}
+			assert.Nil(t, loader.LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+				"address": map[string]interface{}{"zip": "84101"},
+			}}, &args))
+			assert.Equal(t, "84101", args.Address.Zip)
+
+			assert.Equal(t, "TestConcurrentOutput", tb.OutputType("TestConcurrentOutput", "", testConcurrentOutput{}).Name())
+		}(i)
+	}
+	wg.Wait()
+
+	// every goroutine got the same object.
+	assert.Equal(t, tb.OutputType("Other", "", testConcurrentOutput{}), tb.OutputType("TestConcurrentOutput", "", &testConcurrentOutput{}))
+}
+
+func loadUpperString(i interface{}) (string, error) {
+	s, ok := i.(string)
+	if !ok {
+		return "", errors.New("not a string")
+	}
+	return strings.ToUpper(s), nil
+}
+
+func TestCloneOverride(t *testing.T) {
+	loader := Clone()
+	assert.Nil(t, loader.Override(loadUpperString, graphql.String))
+
+	params := graphql.ResolveParams{Args: map[string]interface{}{"id": "bob"}}
+	args := testRequiredArgs{}
+	assert.Nil(t, loader.LoadArgs(params, &args))
+	assert.Equal(t, "BOB", args.ID)
+
+	// the default loader is untouched.
+	assert.Nil(t, LoadArgs(params, &args))
+	assert.Equal(t, "bob", args.ID)
+
+	// RegisterArgParser still refuses to replace a loader.
+	assert.NotNil(t, loader.RegisterArgParser(loadUpperString, graphql.String))
+}
+
+func TestMerge(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+
+	other := Empty()
+	assert.Nil(t, other.RegisterArgParser(loadUpperString, graphql.String))
+	assert.Nil(t, other.RegisterArgParser(loadTestLength, graphql.Int))
+	assert.Nil(t, loader.Merge(other))
+
+	args := testPlanArgs{}
+	assert.Nil(t, loader.LoadArgs(testPlanParams, &args))
+	assert.Equal(t, "BOB", args.ID)
+	assert.Equal(t, testLengthArg(12), args.Length)
+
+	loader.Freeze()
+	assert.Equal(t, ErrFrozen, loader.Merge(other))
+	assert.Equal(t, ErrFrozen, loader.Override(loadUpperString, graphql.String))
+}
+
+func TestInputObjectsReset(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+	fieldType := func(name string) string {
+		conf, err := loader.SafeArgsConfig(testNestedArgs{})
+		assert.Nil(t, err)
+		return conf["address"].Type.(*graphql.InputObject).Fields()[name].Type.String()
+	}
+	assert.Equal(t, "String!", fieldType("zip"))
+
+	// settings and registrations made after an input object is built show up in it.
+	loader.AllowNullRequired(true)
+	assert.Equal(t, "String", fieldType("zip"))
+	assert.Nil(t, loader.Override(loadUpperString, graphql.ID))
+	assert.Equal(t, "ID", fieldType("street"))
+
+	other := Empty()
+	assert.Nil(t, other.RegisterArgParser(loadUpperString, graphql.String))
+	assert.Nil(t, loader.Merge(other))
+	assert.Equal(t, "String", fieldType("street"))
+}
+
+type testSharedAddress struct {
+	Street string `arg:"street"`
+}
+
+type testShipArgs struct {
+	Address testSharedAddress `arg:"address"`
+}
+
+type testBillArgs struct {
+	Address testSharedAddress `arg:"address"`
+	Amount  int               `arg:"amount"`
+}
+
+type testSharedCode string
+
+func TestInputObjectsAcrossRegistration(t *testing.T) {
+	loader, err := New()
+	assert.Nil(t, err)
+
+	// field configs built before and after a registration share one input object, so they can be
+	// used in the same schema.
+	ship := loader.ArgsConfig(testShipArgs{})
+	assert.Nil(t, loader.RegisterArgParser(func(i interface{}) (testSharedCode, error) {
+		s, _ := i.(string)
+		return testSharedCode(s), nil
+	}, graphql.String))
+	bill := loader.ArgsConfig(testBillArgs{})
+	assert.Same(t, ship["address"].Type, bill["address"].Type)
+
+	_, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"ship": &graphql.Field{Type: graphql.Boolean, Args: ship},
+				"bill": &graphql.Field{Type: graphql.Boolean, Args: bill},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+
+	// a different struct can't take the same name.
+	_, err = loader.SafeArgsConfig(struct {
+		Address struct {
+			Zip string `arg:"zip"`
+		} `arg:"testSharedAddress"`
+	}{})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "input object TestSharedAddressInput, which already describes sugar.testSharedAddress")
+	}
+}
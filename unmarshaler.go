@@ -0,0 +1,88 @@
+package sugar
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+)
+
+// ArgUnmarshaler is implemented by types that know how to load themselves from a GraphQL argument.
+// ArgLoaders use it for any type that doesn't have a registered loader func, so such types work
+// without calling RegisterArgParser.
+type ArgUnmarshaler interface {
+	// UnmarshalGraphQLArg sets the receiver from the value graphql-go provides for the argument.
+	UnmarshalGraphQLArg(interface{}) error
+	// GraphQLInputType returns the GraphQL type for arguments of this type.  It's called on a zero
+	// value.
+	GraphQLInputType() graphql.Input
+}
+
+// a loaderUnmarshaler is an ArgUnmarshaler whose loading and GraphQL type depend on the ArgLoader
+// using it, like Where and OrderTerms.  ArgLoaders call these methods instead of the ArgUnmarshaler
+// ones.
+type loaderUnmarshaler interface {
+	unmarshalArgWith(e *ArgLoader, i interface{}) error
+	inputTypeWith(e *ArgLoader) (graphql.Input, error)
+}
+
+var (
+	argUnmarshalerType    = reflect.TypeOf((*ArgUnmarshaler)(nil)).Elem()
+	loaderUnmarshalerType = reflect.TypeOf((*loaderUnmarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unmarshalerArgType returns the GraphQL type for t if a pointer to t implements ArgUnmarshaler or
+// encoding.TextUnmarshaler.  TextUnmarshalers are loaded from strings.  An error means t is an
+// unmarshaler whose type can't be built.
+func (e *ArgLoader) unmarshalerArgType(t reflect.Type) (graphql.Input, bool, error) {
+	ptrType := reflect.PtrTo(t)
+	switch {
+	case ptrType.Implements(loaderUnmarshalerType):
+		argType, err := reflect.New(t).Interface().(loaderUnmarshaler).inputTypeWith(e)
+		return argType, true, err
+	case ptrType.Implements(argUnmarshalerType):
+		return reflect.New(t).Interface().(ArgUnmarshaler).GraphQLInputType(), true, nil
+	case ptrType.Implements(textUnmarshalerType):
+		return graphql.String, true, nil
+	}
+	return nil, false, nil
+}
+
+// unmarshalerLoader returns a loader for t if a pointer to t implements ArgUnmarshaler or
+// encoding.TextUnmarshaler.
+func (e *ArgLoader) unmarshalerLoader(t reflect.Type) (func(interface{}) (reflect.Value, error), bool) {
+	ptrType := reflect.PtrTo(t)
+	switch {
+	case ptrType.Implements(loaderUnmarshalerType):
+		return func(i interface{}) (reflect.Value, error) {
+			ptr := reflect.New(t)
+			if err := ptr.Interface().(loaderUnmarshaler).unmarshalArgWith(e, i); err != nil {
+				return reflect.Value{}, err
+			}
+			return ptr.Elem(), nil
+		}, true
+	case ptrType.Implements(argUnmarshalerType):
+		return func(i interface{}) (reflect.Value, error) {
+			ptr := reflect.New(t)
+			if err := ptr.Interface().(ArgUnmarshaler).UnmarshalGraphQLArg(i); err != nil {
+				return reflect.Value{}, err
+			}
+			return ptr.Elem(), nil
+		}, true
+	case ptrType.Implements(textUnmarshalerType):
+		return func(i interface{}) (reflect.Value, error) {
+			s, ok := i.(string)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("%v is not a string", i)
+			}
+			ptr := reflect.New(t)
+			if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return reflect.Value{}, err
+			}
+			return ptr.Elem(), nil
+		}, true
+	}
+	return nil, false
+}
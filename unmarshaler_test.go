@@ -0,0 +1,85 @@
+package sugar
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testColor struct {
+	R, G, B int
+}
+
+func (c *testColor) UnmarshalGraphQLArg(i interface{}) error {
+	rgb, ok := i.([]interface{})
+	if !ok || len(rgb) != 3 {
+		return errors.New("a color needs three components")
+	}
+	for idx, p := range []*int{&c.R, &c.G, &c.B} {
+		n, ok := rgb[idx].(int)
+		if !ok {
+			return errors.New("color components must be ints")
+		}
+		*p = n
+	}
+	return nil
+}
+
+func (c testColor) GraphQLInputType() graphql.Input {
+	return graphql.NewList(graphql.Int)
+}
+
+type testSlug string
+
+func (s *testSlug) UnmarshalText(text []byte) error {
+	if strings.Contains(string(text), " ") {
+		return errors.New("slugs cannot contain spaces")
+	}
+	*s = testSlug(text)
+	return nil
+}
+
+type testUnmarshalerArgs struct {
+	Color   testColor   `arg:"color"`
+	Palette []testColor `arg:"palette"`
+	Slug    *testSlug   `arg:"slug"`
+	Address net.IP      `arg:"address"`
+}
+
+func TestArgUnmarshaler(t *testing.T) {
+	conf, err := SafeArgsConfig(testUnmarshalerArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, "[Int]", conf["color"].Type.String())
+	assert.Equal(t, "[[Int]]", conf["palette"].Type.String())
+	assert.Equal(t, "String", conf["slug"].Type.String())
+	assert.Equal(t, "String", conf["address"].Type.String())
+
+	args := testUnmarshalerArgs{}
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"color":   []interface{}{1, 2, 3},
+		"palette": []interface{}{[]interface{}{4, 5, 6}},
+		"slug":    "a-slug",
+		"address": "10.0.0.1",
+	}}, &args)
+	assert.Nil(t, err)
+	slug := testSlug("a-slug")
+	assert.Equal(t, testUnmarshalerArgs{
+		Color:   testColor{1, 2, 3},
+		Palette: []testColor{{4, 5, 6}},
+		Slug:    &slug,
+		Address: net.ParseIP("10.0.0.1"),
+	}, args)
+
+	err = LoadArgs(graphql.ResolveParams{Args: map[string]interface{}{
+		"color": []interface{}{1, 2},
+		"slug":  "a slug",
+	}}, &args)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "color is not valid: a color needs three components")
+		assert.Contains(t, err.Error(), "slug is not valid: slugs cannot contain spaces")
+	}
+}
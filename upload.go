@@ -0,0 +1,323 @@
+package sugar
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Upload is a file sent with a GraphQL multipart request, as described at
+// https://github.com/jaydenseric/graphql-multipart-request-spec.  Arguments of type Upload or
+// *Upload are loaded by ArgLoaders, and described with the UploadScalar type.
+//
+// The Reader reads the file's contents.  It's an *io.SectionReader, so it can also be asserted to
+// an io.Seeker or io.ReaderAt.  A file given for several arguments has a separate reader for each.
+// It's only valid until the UploadHandler's request is done.
+type Upload struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	io.Reader
+}
+
+// UnmarshalGraphQLArg implements ArgUnmarshaler.
+func (u *Upload) UnmarshalGraphQLArg(i interface{}) error {
+	switch v := i.(type) {
+	case *Upload:
+		*u = *v
+		return nil
+	case Upload:
+		*u = v
+		return nil
+	case uploadToken:
+		return fmt.Errorf("%v is not a file of this request", i)
+	}
+	return fmt.Errorf("%v is not an uploaded file", i)
+}
+
+// GraphQLInputType implements ArgUnmarshaler.
+func (u *Upload) GraphQLInputType() graphql.Input {
+	return UploadScalar
+}
+
+// an uploadToken stands in for a file in a request's variables until LoadArgs finds the file in the
+// request's context.
+type uploadToken string
+
+// uploadsKey is the context key for the files of a request that an UploadHandler is serving, as a
+// map[string]*Upload by their tokens.
+type uploadsKey struct{}
+
+// resolveUploads returns args with the tokens of the files in ctx's request replaced by the files.
+func resolveUploads(ctx context.Context, args map[string]interface{}) map[string]interface{} {
+	if ctx == nil {
+		return args
+	}
+	files, ok := ctx.Value(uploadsKey{}).(map[string]*Upload)
+	if !ok {
+		return args
+	}
+	return replaceUploads(args, files).(map[string]interface{})
+}
+
+// replaceUploads returns a copy of i with the tokens of files replaced by the files.
+func replaceUploads(i interface{}, files map[string]*Upload) interface{} {
+	switch v := i.(type) {
+	case uploadToken:
+		if u, ok := files[string(v)]; ok {
+			return u
+		}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = replaceUploads(item, files)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for j, item := range v {
+			out[j] = replaceUploads(item, files)
+		}
+		return out
+	}
+	return i
+}
+
+func uploadSerialize(value interface{}) interface{} {
+	// uploads only go one way.
+	return nil
+}
+
+func uploadParseValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *Upload:
+		return value
+	case Upload:
+		return &value
+	case string:
+		// the file is looked up when loading arguments, since only the resolver's context knows
+		// which request it came with.
+		if strings.HasPrefix(value, uploadTokenPrefix) {
+			return uploadToken(value)
+		}
+	}
+	return nil
+}
+
+func uploadParseLiteral(valueAST ast.Value) interface{} {
+	// files can only be sent in variables.
+	return nil
+}
+
+// UploadScalar is the GraphQL type for an Upload.  Files can only be given to it in the variables
+// of a multipart request handled by an UploadHandler, and are only found by LoadArgs in resolvers
+// run with that request's context, as graphql-go's handler does.
+var UploadScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "Upload",
+	Description:  "A file sent with a GraphQL multipart request.",
+	Serialize:    uploadSerialize,
+	ParseValue:   uploadParseValue,
+	ParseLiteral: uploadParseLiteral,
+})
+
+// UploadConfig sets the limits for an UploadHandler.  Zero values get the defaults.
+type UploadConfig struct {
+	// MaxRequestSize caps the size of a whole multipart request, in bytes.  It defaults to 32 MB.
+	MaxRequestSize int64
+	// MaxFileSize caps the size of each file, in bytes.  It defaults to MaxRequestSize.
+	MaxFileSize int64
+	// MaxMemory is how many bytes of the files are held in memory.  The rest are spooled to
+	// temporary files, which are removed when the request is done.  It defaults to 10 MB.
+	MaxMemory int64
+}
+
+// The defaults for UploadConfig.
+const (
+	DefaultMaxRequestSize = 32 << 20
+	DefaultMaxMemory      = 10 << 20
+)
+
+// UploadHandler handles GraphQL multipart requests by turning them into the JSON requests that
+// graphql-go's handler understands.  A token for each file is put into the variables at the paths
+// given in the request's map, and the files are put in the request's context, where LoadArgs finds
+// them.  Requests that aren't multipart are passed through untouched.
+type UploadHandler struct {
+	next   http.Handler
+	config UploadConfig
+}
+
+// NewUploadHandler returns an UploadHandler that passes requests on to next, which will usually be
+// a handler from github.com/graphql-go/handler.
+func NewUploadHandler(next http.Handler, config UploadConfig) *UploadHandler {
+	if config.MaxRequestSize <= 0 {
+		config.MaxRequestSize = DefaultMaxRequestSize
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = config.MaxRequestSize
+	}
+	if config.MaxMemory <= 0 {
+		config.MaxMemory = DefaultMaxMemory
+	}
+	return &UploadHandler{next: next, config: config}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *UploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Method != http.MethodPost || mediaType != "multipart/form-data" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxRequestSize)
+	if err := r.ParseMultipartForm(h.config.MaxMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("invalid multipart request: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := map[string]*Upload{}
+	body, opened, status, err := h.rewrite(r.MultipartForm, files)
+	defer func() {
+		for _, f := range opened {
+			f.Close()
+		}
+	}()
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	r = r.Clone(context.WithValue(r.Context(), uploadsKey{}, files))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.MultipartForm = nil
+	r.PostForm = nil
+	r.Form = nil
+	h.next.ServeHTTP(w, r)
+}
+
+// rewrite returns the request's operations as JSON, with a token in place of each file, and adds
+// the files to files by their tokens.  It also returns the files it opened, which the caller must
+// close.  On failure it returns the HTTP status to respond with.
+func (h *UploadHandler) rewrite(form *multipart.Form, files map[string]*Upload) ([]byte, []multipart.File, int, error) {
+	if len(form.Value["operations"]) != 1 || len(form.Value["map"]) != 1 {
+		return nil, nil, http.StatusBadRequest, errors.New("a multipart request needs one operations field and one map field")
+	}
+	var operations interface{}
+	dec := json.NewDecoder(strings.NewReader(form.Value["operations"][0]))
+	// keep numbers as they were sent.
+	dec.UseNumber()
+	if err := dec.Decode(&operations); err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("invalid operations: %v", err)
+	}
+	var fileMap map[string][]string
+	if err := json.Unmarshal([]byte(form.Value["map"][0]), &fileMap); err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("invalid map: %v", err)
+	}
+
+	opened := []multipart.File{}
+	for key, paths := range fileMap {
+		headers := form.File[key]
+		if len(headers) != 1 {
+			return nil, opened, http.StatusBadRequest, fmt.Errorf("file %s is missing", key)
+		}
+		header := headers[0]
+		if header.Size > h.config.MaxFileSize {
+			return nil, opened, http.StatusRequestEntityTooLarge, fmt.Errorf("file %s is too large", key)
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, opened, http.StatusInternalServerError, fmt.Errorf("cannot open file %s: %v", key, err)
+		}
+		opened = append(opened, f)
+
+		for _, path := range paths {
+			// each path gets its own token and reader, so that reading the file for one argument
+			// doesn't use it up for the others.
+			token, err := newUploadToken()
+			if err != nil {
+				return nil, opened, http.StatusInternalServerError, err
+			}
+			files[token] = &Upload{
+				Filename:    header.Filename,
+				ContentType: header.Header.Get("Content-Type"),
+				Size:        header.Size,
+				Reader:      io.NewSectionReader(f, 0, header.Size),
+			}
+			if err := setOperationPath(operations, path, token); err != nil {
+				return nil, opened, http.StatusBadRequest, fmt.Errorf("cannot put file %s at %s: %v", key, path, err)
+			}
+		}
+	}
+
+	body, err := json.Marshal(operations)
+	if err != nil {
+		return nil, opened, http.StatusInternalServerError, err
+	}
+	return body, opened, http.StatusOK, nil
+}
+
+// setOperationPath replaces the value at a dotted path like "variables.files.0" with value.
+func setOperationPath(operations interface{}, path, value string) error {
+	parts := strings.Split(path, ".")
+	current := operations
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch c := current.(type) {
+		case map[string]interface{}:
+			if _, ok := c[part]; !ok {
+				return fmt.Errorf("%s is not in the operations", strings.Join(parts[:i+1], "."))
+			}
+			if last {
+				c[part] = value
+				return nil
+			}
+			current = c[part]
+		case []interface{}:
+			j, err := strconv.Atoi(part)
+			if err != nil || j < 0 || j >= len(c) {
+				return fmt.Errorf("%s is not in the operations", strings.Join(parts[:i+1], "."))
+			}
+			if last {
+				c[j] = value
+				return nil
+			}
+			current = c[j]
+		default:
+			return fmt.Errorf("%s is not in the operations", strings.Join(parts[:i+1], "."))
+		}
+	}
+	return nil
+}
+
+// uploadTokenPrefix starts the tokens that stand in for files.
+const uploadTokenPrefix = "upload:"
+
+// newUploadToken returns a random token to stand in for a file in a request's variables.
+func newUploadToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return uploadTokenPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,217 @@
+package sugar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUploadArgs struct {
+	File  Upload    `arg:"file,required"`
+	Extra []*Upload `arg:"extra"`
+}
+
+// testUploadSchema has a mutation that returns the names and contents of the files it's given.
+func testUploadSchema(t *testing.T) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"upload": &graphql.Field{
+					Type: graphql.NewList(graphql.String),
+					Args: ArgsConfig(testUploadArgs{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args := testUploadArgs{}
+						if err := LoadArgs(p, &args); err != nil {
+							return nil, err
+						}
+						out := []string{}
+						for _, u := range append([]*Upload{&args.File}, args.Extra...) {
+							b, err := io.ReadAll(u)
+							if err != nil {
+								return nil, err
+							}
+							out = append(out, u.Filename+" "+u.ContentType+": "+string(b))
+						}
+						return out, nil
+					},
+				},
+			},
+		}),
+	})
+	assert.Nil(t, err)
+	return schema
+}
+
+// testJSONHandler stands in for graphql-go's handler, which also runs queries with the request's
+// context.
+func testJSONHandler(schema graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			Context:        r.Context(),
+		}))
+	})
+}
+
+type testUploadFile struct {
+	key, name, content string
+}
+
+func testMultipartRequest(t *testing.T, operations, fileMap string, files ...testUploadFile) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	assert.Nil(t, mw.WriteField("operations", operations))
+	assert.Nil(t, mw.WriteField("map", fileMap))
+	for _, f := range files {
+		fw, err := mw.CreateFormFile(f.key, f.name)
+		assert.Nil(t, err)
+		_, err = fw.Write([]byte(f.content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, mw.Close())
+	r := httptest.NewRequest(http.MethodPost, "/graphql", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestUploadHandler(t *testing.T) {
+	h := NewUploadHandler(testJSONHandler(testUploadSchema(t)), UploadConfig{MaxFileSize: 10})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, testMultipartRequest(t,
+		`{"query": "mutation($f: Upload!, $e: [Upload]) { upload(file: $f, extra: $e) }", "variables": {"f": null, "e": [null]}}`,
+		`{"0": ["variables.f"], "1": ["variables.e.0"]}`,
+		testUploadFile{"0", "a.txt", "hello"},
+		testUploadFile{"1", "b.txt", "world"},
+	))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": {"upload": [
+		"a.txt application/octet-stream: hello",
+		"b.txt application/octet-stream: world"
+	]}}`, w.Body.String())
+
+	// a file given for several arguments can be read for each of them.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, testMultipartRequest(t,
+		`{"query": "mutation($f: Upload!, $e: [Upload]) { upload(file: $f, extra: $e) }", "variables": {"f": null, "e": [null]}}`,
+		`{"0": ["variables.f", "variables.e.0"]}`,
+		testUploadFile{"0", "a.txt", "hello"},
+	))
+	assert.JSONEq(t, `{"data": {"upload": [
+		"a.txt application/octet-stream: hello",
+		"a.txt application/octet-stream: hello"
+	]}}`, w.Body.String())
+
+	// files can't be given in the query.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, testMultipartRequest(t, `{"query": "mutation { upload(file: \"upload:x\") }"}`, `{}`))
+	assert.Contains(t, w.Body.String(), "errors")
+
+	// JSON requests pass through.
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ ok }"}`))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(w, r)
+	assert.JSONEq(t, `{"data": {"ok": null}}`, w.Body.String())
+}
+
+func TestUploadHandlerErrors(t *testing.T) {
+	h := NewUploadHandler(testJSONHandler(testUploadSchema(t)), UploadConfig{MaxFileSize: 4, MaxRequestSize: 1024})
+	operations := `{"query": "mutation($f: Upload!) { upload(file: $f) }", "variables": {"f": null}}`
+
+	tests := []struct {
+		name       string
+		operations string
+		fileMap    string
+		files      []testUploadFile
+		status     int
+	}{
+		{"file too large", operations, `{"0": ["variables.f"]}`, []testUploadFile{{"0", "a.txt", "hello"}}, http.StatusRequestEntityTooLarge},
+		{"request too large", operations, `{"0": ["variables.f"]}`, []testUploadFile{{"0", "a.txt", strings.Repeat("x", 2048)}}, http.StatusRequestEntityTooLarge},
+		{"missing file", operations, `{"0": ["variables.f"]}`, nil, http.StatusBadRequest},
+		{"bad path", operations, `{"0": ["variables.g"]}`, []testUploadFile{{"0", "a.txt", "hi"}}, http.StatusBadRequest},
+		{"bad map", operations, `[]`, nil, http.StatusBadRequest},
+		{"bad operations", `{`, `{}`, nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, testMultipartRequest(t, tt.operations, tt.fileMap, tt.files...))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestUploadContext(t *testing.T) {
+	files := map[string]*Upload{"upload:a": {Filename: "a.txt", Reader: strings.NewReader("a")}}
+	ctx := context.WithValue(context.Background(), uploadsKey{}, files)
+	token := uploadParseValue("upload:a")
+
+	args := testUploadArgs{}
+	err := LoadArgs(graphql.ResolveParams{Context: ctx, Args: map[string]interface{}{
+		"file":  token,
+		"extra": []interface{}{token},
+	}}, &args)
+	assert.Nil(t, err)
+	assert.Equal(t, "a.txt", args.File.Filename)
+	if assert.Len(t, args.Extra, 1) {
+		assert.Equal(t, "a.txt", args.Extra[0].Filename)
+	}
+
+	// tokens are only good in the context of the request that sent the files.
+	for _, ctx := range []context.Context{nil, context.Background(), context.WithValue(ctx, uploadsKey{}, map[string]*Upload{})} {
+		err = LoadArgs(graphql.ResolveParams{Context: ctx, Args: map[string]interface{}{"file": token}}, &args)
+		if assert.IsType(t, ArgErrors{}, err) {
+			assert.Equal(t, "file", err.(ArgErrors)[0].Path)
+		}
+	}
+}
+
+type testUploadInput struct {
+	Avatar *Upload `json:"avatar"`
+}
+
+func TestUploadInputType(t *testing.T) {
+	tb := NewTypeBuilder()
+	input := tb.InputType("AvatarInput", "", testUploadInput{}).(*graphql.InputObject)
+	assert.Equal(t, UploadScalar, input.Fields()["avatar"].Type)
+
+	// uploads aren't known as output types.
+	_, ok := (*tb.knownTypes.Load())[reflect.TypeOf(Upload{})]
+	assert.False(t, ok)
+}
+
+func TestSetOperationPath(t *testing.T) {
+	operations := []interface{}{
+		map[string]interface{}{"variables": map[string]interface{}{"files": []interface{}{nil, nil}}},
+	}
+	assert.Nil(t, setOperationPath(operations, "0.variables.files.1", "x"))
+	assert.Equal(t, []interface{}{nil, "x"}, operations[0].(map[string]interface{})["variables"].(map[string]interface{})["files"])
+	assert.NotNil(t, setOperationPath(operations, "0.variables.files.2", "x"))
+	assert.NotNil(t, setOperationPath(operations, "1.variables", "x"))
+}
@@ -0,0 +1,295 @@
+package sugar
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A ValidatorFunc checks a loaded argument value against the parameter given in its struct tag.
+// For `arg:"name,max:100"`, the "max" validator is called with the loaded name and "100".  Errors
+// should describe the problem in a way that reads well after the argument name, like "must be at
+// most 500".
+type ValidatorFunc func(v interface{}, param string) error
+
+// BuiltinValidators are registered on every ArgLoader, and can be used as options in the arg tag.
+// The parameters of min, max, len, and pattern are parsed once, when an arg struct is first used,
+// and a bad one is reported by SafeArgsConfig and LoadArgs rather than as a validation error.
+var BuiltinValidators = map[string]ValidatorFunc{
+	"min":      ValidateMin,
+	"max":      ValidateMax,
+	"len":      ValidateLen,
+	"pattern":  ValidatePattern,
+	"oneof":    ValidateOneOf,
+	"email":    ValidateEmail,
+	"nonempty": ValidateNonEmpty,
+}
+
+// a validatorCompiler parses a validator's tag parameter, and returns the check to run against each
+// loaded value.
+type validatorCompiler func(param string) (func(v interface{}) error, error)
+
+// builtinCompilers parse the parameters of the builtin validators ahead of time.
+var builtinCompilers = map[string]validatorCompiler{
+	"min":     compileMin,
+	"max":     compileMax,
+	"len":     compileLen,
+	"pattern": compilePattern,
+}
+
+// compilerFor returns a validatorCompiler that calls f with the unparsed parameter.
+func compilerFor(f ValidatorFunc) validatorCompiler {
+	return func(param string) (func(v interface{}) error, error) {
+		return func(v interface{}) error { return f(v, param) }, nil
+	}
+}
+
+// builtinValidators returns the compilers for BuiltinValidators.
+func builtinValidators() map[string]validatorCompiler {
+	validators := make(map[string]validatorCompiler, len(BuiltinValidators))
+	for name, f := range BuiltinValidators {
+		if c, ok := builtinCompilers[name]; ok {
+			validators[name] = c
+		} else {
+			validators[name] = compilerFor(f)
+		}
+	}
+	return validators
+}
+
+// RegisterValidator makes f available as a named option in the arg tag.  When an argument with
+// that option is loaded, f is called with the loaded value and the option's parameter.
+func (e *ArgLoader) RegisterValidator(name string, f ValidatorFunc) error {
+	return e.update(func(reg *argRegistry) error {
+		if _, ok := reg.validators[name]; ok {
+			return fmt.Errorf("a validator has already been registered with the name %s", name)
+		}
+		reg.validators[name] = compilerFor(f)
+		return nil
+	})
+}
+
+// fieldValidator is a validator resolved from a tag option, with its parameter already applied.
+type fieldValidator struct {
+	check func(v interface{}) error
+}
+
+// compileValidators looks up the validators named in config, and parses their parameters.
+func (e *ArgLoader) compileValidators(config TagOptions) ([]fieldValidator, error) {
+	// sort the options so that errors come out in a stable order.
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	validators := []fieldValidator{}
+	for _, key := range keys {
+		compile, ok := e.reg.Load().validators[key]
+		if !ok {
+			continue
+		}
+		check, err := compile(config[key])
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, fieldValidator{check: check})
+	}
+	return validators, nil
+}
+
+// ValidateMin checks that a number is at least param, or that a string, slice, or map has at least
+// param items.
+func ValidateMin(v interface{}, param string) error {
+	return runCompiled(compileMin, v, param)
+}
+
+func compileMin(param string) (func(v interface{}) error, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil, fmt.Errorf("has an invalid min %q", param)
+	}
+	return func(v interface{}) error {
+		n, isLength, ok := measure(v)
+		if !ok || n >= limit {
+			return nil
+		}
+		if isLength {
+			return fmt.Errorf("must have a length of at least %s", param)
+		}
+		return fmt.Errorf("must be at least %s", param)
+	}, nil
+}
+
+// ValidateMax checks that a number is at most param, or that a string, slice, or map has at most
+// param items.
+func ValidateMax(v interface{}, param string) error {
+	return runCompiled(compileMax, v, param)
+}
+
+func compileMax(param string) (func(v interface{}) error, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil, fmt.Errorf("has an invalid max %q", param)
+	}
+	return func(v interface{}) error {
+		n, isLength, ok := measure(v)
+		if !ok || n <= limit {
+			return nil
+		}
+		if isLength {
+			return fmt.Errorf("must have a length of at most %s", param)
+		}
+		return fmt.Errorf("must be at most %s", param)
+	}, nil
+}
+
+// ValidateLen checks that a string, slice, or map has exactly param items.
+func ValidateLen(v interface{}, param string) error {
+	return runCompiled(compileLen, v, param)
+}
+
+func compileLen(param string) (func(v interface{}) error, error) {
+	want, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, fmt.Errorf("has an invalid len %q", param)
+	}
+	return func(v interface{}) error {
+		n, isLength, ok := measure(v)
+		if !ok || !isLength || int(n) == want {
+			return nil
+		}
+		return fmt.Errorf("must have a length of %d", want)
+	}, nil
+}
+
+// ValidatePattern checks that a string matches the regular expression in param.  The pattern cannot
+// contain the tag's option separator, which is a comma by default.
+func ValidatePattern(v interface{}, param string) error {
+	return runCompiled(compilePattern, v, param)
+}
+
+func compilePattern(param string) (func(v interface{}) error, error) {
+	re, err := regexp.Compile(param)
+	if err != nil {
+		return nil, fmt.Errorf("has an invalid pattern %q", param)
+	}
+	return func(v interface{}) error {
+		s, ok := stringValue(v)
+		if ok && !re.MatchString(s) {
+			return fmt.Errorf("must match %s", param)
+		}
+		return nil
+	}, nil
+}
+
+// runCompiled compiles param and runs the check against v, for callers of the ValidatorFunc forms.
+func runCompiled(compile validatorCompiler, v interface{}, param string) error {
+	check, err := compile(param)
+	if err != nil {
+		return err
+	}
+	return check(v)
+}
+
+// ValidateOneOf checks that a value is one of the |-separated choices in param, as in
+// `arg:"color,oneof:red|green|blue"`.
+func ValidateOneOf(v interface{}, param string) error {
+	rv := unwrapValue(v)
+	if !rv.IsValid() {
+		return nil
+	}
+	s := fmt.Sprint(rv.Interface())
+	choices := strings.Split(param, "|")
+	for _, c := range choices {
+		if s == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(choices, ", "))
+}
+
+// ValidateEmail checks that a string is a bare email address, without a display name.
+func ValidateEmail(v interface{}, param string) error {
+	s, ok := stringValue(v)
+	if !ok {
+		return nil
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return fmt.Errorf("must be an email address")
+	}
+	return nil
+}
+
+// ValidateNonEmpty checks that a string, slice, or map is not empty, or that any other value is not
+// its zero value.
+func ValidateNonEmpty(v interface{}, param string) error {
+	n, isLength, ok := measure(v)
+	if ok && isLength {
+		if n == 0 {
+			return fmt.Errorf("must not be empty")
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() && nullWrapper(rv.Type()) {
+		rv = unwrapValue(v)
+	}
+	if !rv.IsValid() || rv.IsZero() {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+// measure returns the number that min and max compare against: the value of a number, or the
+// length of a string, slice, array, or map.  ok is false for nil pointers, null wrappers, and other
+// kinds.
+func measure(v interface{}) (n float64, isLength bool, ok bool) {
+	rv := unwrapValue(v)
+	if !rv.IsValid() {
+		return 0, false, false
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(rv.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(rv.Len()), true, true
+	}
+	return 0, false, false
+}
+
+// stringValue returns the string held by v, following pointers and unwrapping nullable wrappers.
+func stringValue(v interface{}) (string, bool) {
+	rv := unwrapValue(v)
+	if !rv.IsValid() || rv.Kind() != reflect.String {
+		return "", false
+	}
+	return rv.String(), true
+}
+
+// unwrapValue follows a pointer in v, and unwraps nullable wrappers like sql.NullInt64 into the
+// value they hold, so that validators check what was sent.  It returns an invalid value for nil
+// pointers and null wrappers.
+func unwrapValue(v interface{}) reflect.Value {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.IsValid() && nullWrapper(rv.Type()) {
+		value, err := rv.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(value)
+	}
+	return rv
+}